@@ -18,6 +18,7 @@ package main
 
 import . "engine"
 import "fmt"
+import "tsumego"
 
 func main() {
   state := NewGameState(6, 6, 0.0, "..xxx." +
@@ -26,6 +27,21 @@ func main() {
                                    "xo.oox" +
                                    "xooox." +
                                    ".xxx.x")
-  y, x := GetBestMove(state, BLACK, 30)
-  fmt.Printf("move %d %d\n", y, x)
+  solution, err := tsumego.Solve(&tsumego.Problem{
+    Goban: state.GetGoban(),
+    TargetY: 1, TargetX: 2,
+    ToPlay: BLACK,
+    Goal: tsumego.KILL,
+    Region: tsumego.Enclosure(state.GetGoban(), 1, 2),
+  })
+  if err != nil {
+    fmt.Println(err)
+    return
+  }
+  if solution.Result == tsumego.LOSS {
+    fmt.Println("no solution")
+    return
+  }
+  move := solution.Tree.Children[0].Move
+  fmt.Printf("%v: move %d %d\n", solution.Result, move.Y, move.X)
 }
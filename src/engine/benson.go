@@ -0,0 +1,228 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Benson's algorithm for unconditional life.
+//
+// The blocks of a color are its chains, and the regions are the maximal
+// connected sets of points without stones of that color. A region is
+// vital to a block if all its empty points are liberties of the block.
+// Blocks with less than two vital regions are removed, then the regions
+// next to a removed block, until nothing changes. The blocks left are
+// alive even if their owner always passes, and the regions vital to them
+// are pass-alive territory, where any opponent stones are dead.
+
+// Label the connected components of the points accepted by member, with
+// -1 for the other points.
+func labelComponents(g Goban, member func (color Color) bool) ([]int, int) {
+  labels := make([]int, g.SizeY() * g.SizeX())
+  for i := range labels {
+    labels[i] = -1
+  }
+  count := 0
+  stack := make([]Position, 0, len(labels))
+  iterateAll(g, func (y, x int) {
+    if labels[encode(g, y, x)] >= 0 || !member(g.GetColor(y, x)) {
+      return
+    }
+    labels[encode(g, y, x)] = count
+    stack = append(stack[:0], Position{y, x})
+    for len(stack) > 0 {
+      p := stack[len(stack) - 1]
+      stack = stack[:len(stack) - 1]
+      iterateNeighbours(g, p.y, p.x, func (ny, nx int) {
+        if labels[encode(g, ny, nx)] < 0 && member(g.GetColor(ny, nx)) {
+          labels[encode(g, ny, nx)] = count
+          stack = append(stack, Position{ny, nx})
+        }
+      })
+    }
+    count++
+  })
+  return labels, count
+}
+
+// Points of the unconditionally alive blocks of color, and of their
+// pass-alive territory.
+func Benson(g Goban, color Color) (alive, territory []bool) {
+  blocks, block_count := labelComponents(g, func (c Color) bool {
+    return c == color
+  })
+  regions, region_count := labelComponents(g, func (c Color) bool {
+    return c != color && c != INVALID
+  })
+  // For each region, its neighbour blocks and the blocks it's vital to.
+  neighbours := make([]map[int]bool, region_count)
+  vital := make([]map[int]bool, region_count)
+  for i := range neighbours {
+    neighbours[i] = make(map[int]bool)
+  }
+  iterateAll(g, func (y, x int) {
+    region := regions[encode(g, y, x)]
+    if region < 0 {
+      return
+    }
+    adjacent := make(map[int]bool)
+    iterateNeighbours(g, y, x, func (ny, nx int) {
+      if block := blocks[encode(g, ny, nx)]; block >= 0 {
+        neighbours[region][block] = true
+        adjacent[block] = true
+      }
+    })
+    if g.GetColor(y, x) != EMPTY {
+      return
+    }
+    if vital[region] == nil {
+      vital[region] = adjacent
+      return
+    }
+    for block := range vital[region] {
+      if !adjacent[block] {
+        delete(vital[region], block)
+      }
+    }
+  })
+  live_blocks := make([]bool, block_count)
+  live_regions := make([]bool, region_count)
+  for i := range live_blocks {
+    live_blocks[i] = true
+  }
+  for i := range live_regions {
+    live_regions[i] = true
+  }
+  for changed := true; changed; {
+    changed = false
+    vital_count := make([]int, block_count)
+    for region := range vital {
+      if live_regions[region] {
+        for block := range vital[region] {
+          vital_count[block]++
+        }
+      }
+    }
+    for block := range live_blocks {
+      if live_blocks[block] && vital_count[block] < 2 {
+        live_blocks[block] = false
+        changed = true
+      }
+    }
+    for region := range neighbours {
+      for block := range neighbours[region] {
+        if live_regions[region] && !live_blocks[block] {
+          live_regions[region] = false
+          changed = true
+        }
+      }
+    }
+  }
+  alive = make([]bool, len(blocks))
+  territory = make([]bool, len(blocks))
+  for p := range blocks {
+    if blocks[p] >= 0 {
+      alive[p] = live_blocks[blocks[p]]
+    }
+    if region := regions[p]; region >= 0 && live_regions[region] {
+      for block := range vital[region] {
+        if live_blocks[block] {
+          territory[p] = true
+        }
+      }
+    }
+  }
+  return alive, territory
+}
+
+// Owner of each point that is settled regardless of how the game goes
+// on: stones of unconditionally alive blocks and points in pass-alive
+// territory, including the dead stones there. Other points are EMPTY.
+func UnconditionalStatus(g Goban) []Color {
+  status := make([]Color, g.SizeY() * g.SizeX())
+  for _, color := range []Color{BLACK, WHITE} {
+    alive, territory := Benson(g, color)
+    for p := range status {
+      if alive[p] || territory[p] {
+        status[p] = color
+      }
+    }
+  }
+  return status
+}
+
+// Copy of the state without the dead stones in pass-alive territory,
+// which are counted as prisoners.
+func (s *GameState) withoutDeadStones() *GameState {
+  status := UnconditionalStatus(s.goban)
+  result := copyState(s)
+  result.goban.SetStack(s.goban.GetStack())
+  iterateAll(s.goban, func (y, x int) {
+    color := s.goban.GetColor(y, x)
+    owner := status[encode(s.goban, y, x)]
+    if (color != BLACK && color != WHITE) || owner != Opposite(color) {
+      return
+    }
+    result.goban.SetColor(y, x, EMPTY)
+    if color == WHITE {
+      result.captured_white++
+    } else {
+      result.captured_black++
+    }
+  })
+  return result
+}
+
+// Final status of the stones.
+const (
+  ALIVE = iota
+  DEAD
+  SEKI
+)
+
+type StoneStatus int
+
+// Final status of every point with a stone, indexed as the goban. Only
+// stones in the pass-alive territory of the opponent are known to be dead,
+// every other stone not in seki is considered alive.
+func (s *GameState) FinalStatus() []StoneStatus {
+  status := UnconditionalStatus(s.goban)
+  seki := SekiPoints(s.goban)
+  result := make([]StoneStatus, len(status))
+  iterateAll(s.goban, func (y, x int) {
+    p := encode(s.goban, y, x)
+    color := s.goban.GetColor(y, x)
+    switch {
+    case (color == BLACK || color == WHITE) && status[p] == Opposite(color):
+      result[p] = DEAD
+    case seki[p]:
+      result[p] = SEKI
+    }
+  })
+  return result
+}
+
+// Remove the moves inside pass-alive territory of either color, since
+// they can't change the outcome.
+func settledMoves(g Goban, moves []Position) []Position {
+  status := UnconditionalStatus(g)
+  result := make([]Position, 0, len(moves))
+  for _, move := range moves {
+    if status[encode(g, move.y, move.x)] == EMPTY {
+      result = append(result, move)
+    }
+  }
+  return result
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestBensonTwoEyes(c *C) {
+  goban := NewChainGoban(4, 5)
+  FromString(goban, ".x.x." +
+                    "xxxxx" +
+                    "ooooo" +
+                    "o.o.o")
+  status := UnconditionalStatus(goban)
+  c.Check(status[encode(goban, 0, 0)], Equals, Color(BLACK))
+  c.Check(status[encode(goban, 1, 2)], Equals, Color(BLACK))
+  c.Check(status[encode(goban, 3, 1)], Equals, Color(WHITE))
+  c.Check(status[encode(goban, 2, 2)], Equals, Color(WHITE))
+}
+
+func (s *S) TestBensonOneEye(c *C) {
+  goban := NewChainGoban(4, 5)
+  FromString(goban, ".xxxx" +
+                    "xxxxx" +
+                    "....." +
+                    ".....")
+  for _, color := range UnconditionalStatus(goban) {
+    c.Check(color, Equals, Color(EMPTY))
+  }
+}
+
+// Black is alive, and the white stone in its eye is dead.
+const benson_dead_board = ".xo.x" +
+                          "xxxxx" +
+                          "....." +
+                          "....."
+
+func (s *S) TestBensonDeadStone(c *C) {
+  state := NewGameState(4, 5, 0.0, benson_dead_board)
+  status := UnconditionalStatus(state.goban)
+  c.Check(status[encode(state.goban, 0, 2)], Equals, Color(BLACK))
+  c.Check(status[encode(state.goban, 0, 3)], Equals, Color(BLACK))
+  c.Check(status[encode(state.goban, 2, 2)], Equals, Color(EMPTY))
+  final := state.FinalStatus()
+  c.Check(final[encode(state.goban, 0, 2)], Equals, StoneStatus(DEAD))
+  c.Check(final[encode(state.goban, 0, 1)], Equals, StoneStatus(ALIVE))
+  c.Check(state.FinalScore(), Equals, float32(20))
+}
+
+func (s *S) TestSettledMoves(c *C) {
+  state := NewGameState(4, 5, 0.0, benson_dead_board)
+  moves := settledMoves(state.goban, GetMoveList(state.goban, WHITE))
+  c.Check(len(moves), Equals, 10)
+  for _, move := range moves {
+    c.Check(move.y >= 2, Equals, true)
+  }
+}
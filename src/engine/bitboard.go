@@ -0,0 +1,297 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "math/bits"
+
+// --------------------------
+// A set of points on boards up to 19x19. Each row has an extra guard
+// column that is always zero, so that shifting by one never moves a point
+// to the next row.
+
+const bitboard_words = 6
+
+type Bitboard [bitboard_words]uint64
+
+func (b *Bitboard) Set(p int) {
+  b[p >> 6] |= 1 << uint(p & 63)
+}
+
+func (b *Bitboard) Clear(p int) {
+  b[p >> 6] &^= 1 << uint(p & 63)
+}
+
+func (b *Bitboard) Has(p int) bool {
+  return b[p >> 6] & (1 << uint(p & 63)) != 0
+}
+
+func (b Bitboard) And(o Bitboard) Bitboard {
+  for i := range b {
+    b[i] &= o[i]
+  }
+  return b
+}
+
+func (b Bitboard) Or(o Bitboard) Bitboard {
+  for i := range b {
+    b[i] |= o[i]
+  }
+  return b
+}
+
+func (b Bitboard) AndNot(o Bitboard) Bitboard {
+  for i := range b {
+    b[i] &^= o[i]
+  }
+  return b
+}
+
+func (b Bitboard) Empty() bool {
+  for _, word := range b {
+    if word != 0 {
+      return false
+    }
+  }
+  return true
+}
+
+func (b Bitboard) Count() int {
+  count := 0
+  for _, word := range b {
+    count += bits.OnesCount64(word)
+  }
+  return count
+}
+
+// Index of the lowest point in the set, or -1 if it's empty.
+func (b Bitboard) First() int {
+  for i, word := range b {
+    if word != 0 {
+      return i * 64 + bits.TrailingZeros64(word)
+    }
+  }
+  return -1
+}
+
+// Shift towards higher indices, 0 < s < 64.
+func (b Bitboard) shiftUp(s uint) Bitboard {
+  var r Bitboard
+  for i := bitboard_words - 1; i > 0; i-- {
+    r[i] = b[i] << s | b[i - 1] >> (64 - s)
+  }
+  r[0] = b[0] << s
+  return r
+}
+
+// Shift towards lower indices, 0 < s < 64.
+func (b Bitboard) shiftDown(s uint) Bitboard {
+  var r Bitboard
+  for i := 0; i < bitboard_words - 1; i++ {
+    r[i] = b[i] >> s | b[i + 1] << (64 - s)
+  }
+  r[bitboard_words - 1] = b[bitboard_words - 1] >> s
+  return r
+}
+
+// --------------------------
+// Goban implementation using one bitboard per color.
+
+type BitboardGoban struct {
+  size_x, size_y int
+  stride uint
+  mask Bitboard
+  stones [3]Bitboard
+  marks Bitboard
+  stack Stack
+}
+
+func NewBitboardGoban(size_y, size_x int) *BitboardGoban {
+  if size_x > 19 || size_y > 19 {
+    panic("engine: BitboardGoban is limited to 19x19")
+  }
+  goban := new(BitboardGoban)
+  goban.size_x = size_x
+  goban.size_y = size_y
+  goban.stride = uint(size_x + 1)
+  for j := 0; j < size_y; j++ {
+    for i := 0; i < size_x; i++ {
+      goban.mask.Set(goban.index(j, i))
+    }
+  }
+  goban.stack = NewSliceStack(size_x * size_y)
+  return goban
+}
+
+// Build a BitboardGoban with the same stones as any other Goban.
+func NewBitboardGobanFrom(g Goban) *BitboardGoban {
+  goban := NewBitboardGoban(g.SizeY(), g.SizeX())
+  iterateAll(g, func (y, x int) {
+    goban.SetColor(y, x, g.GetColor(y, x))
+  })
+  return goban
+}
+
+func (g *BitboardGoban) index(y, x int) int {
+  return y * int(g.stride) + x
+}
+
+func (g *BitboardGoban) Copy() Goban {
+  new_goban := *g
+  new_goban.marks = Bitboard{}
+  new_goban.stack = nil
+  return &new_goban
+}
+
+func (g *BitboardGoban) SizeX() int {
+  return g.size_x
+}
+
+func (g *BitboardGoban) SizeY() int {
+  return g.size_y
+}
+
+// INVALID points are removed from the board mask.
+func (g *BitboardGoban) GetColor(y, x int) Color {
+  p := g.index(y, x)
+  switch {
+  case g.stones[BLACK].Has(p):
+    return BLACK
+  case g.stones[WHITE].Has(p):
+    return WHITE
+  case !g.mask.Has(p):
+    return INVALID
+  }
+  return EMPTY
+}
+
+func (g *BitboardGoban) SetColor(y, x int, color Color) {
+  p := g.index(y, x)
+  g.stones[BLACK].Clear(p)
+  g.stones[WHITE].Clear(p)
+  g.mask.Set(p)
+  switch color {
+  case BLACK, WHITE:
+    g.stones[color].Set(p)
+  case INVALID:
+    g.mask.Clear(p)
+  }
+}
+
+func (g *BitboardGoban) GetVisitorMarker() VisitorMarker {
+  return g
+}
+
+func (g *BitboardGoban) ClearMarks() {
+  g.marks = Bitboard{}
+}
+
+func (g *BitboardGoban) SetMark(y, x int) {
+  g.marks.Set(g.index(y, x))
+}
+
+func (g *BitboardGoban) IsMarked(y, x int) bool {
+  return g.marks.Has(g.index(y, x))
+}
+
+func (g *BitboardGoban) GetStack() Stack {
+  return g.stack
+}
+
+func (g *BitboardGoban) SetStack(stack Stack) {
+  g.stack = stack
+}
+
+// --------------------------
+// Bit-parallel flood fill.
+
+// Points in the set plus their neighbours.
+func (g *BitboardGoban) dilate(b Bitboard) Bitboard {
+  d := b.Or(b.shiftUp(1)).Or(b.shiftDown(1))
+  d = d.Or(b.shiftUp(g.stride)).Or(b.shiftDown(g.stride))
+  return d.And(g.mask)
+}
+
+func (g *BitboardGoban) empty() Bitboard {
+  return g.mask.AndNot(g.stones[BLACK]).AndNot(g.stones[WHITE])
+}
+
+// All stones connected to the stones in seed, with the given color.
+func (g *BitboardGoban) group(seed Bitboard, stones Bitboard) Bitboard {
+  group := seed
+  for {
+    next := g.dilate(group).And(stones)
+    if next == group {
+      return group
+    }
+    group = next
+  }
+}
+
+func (g *BitboardGoban) liberties(group Bitboard, empty Bitboard) Bitboard {
+  return g.dilate(group).And(empty)
+}
+
+func (g *BitboardGoban) Group(y, x int) Bitboard {
+  var seed Bitboard
+  seed.Set(g.index(y, x))
+  return g.group(seed, g.stones[g.GetColor(y, x)])
+}
+
+func (g *BitboardGoban) Liberties(y, x int) int {
+  return g.liberties(g.Group(y, x), g.empty()).Count()
+}
+
+// --------------------------
+// GroupTracker implementation.
+
+func (g *BitboardGoban) HasLiberties(y, x int) bool {
+  return !g.liberties(g.Group(y, x), g.empty()).Empty()
+}
+
+func (g *BitboardGoban) InAtari(y, x int) bool {
+  return g.Liberties(y, x) == 1
+}
+
+func (g *BitboardGoban) Suicide(y, x int, color Color) bool {
+  var stone Bitboard
+  stone.Set(g.index(y, x))
+  empty := g.empty().AndNot(stone)
+  own := g.stones[color].Or(stone)
+  if !g.liberties(g.group(stone, own), empty).Empty() {
+    return false
+  }
+  // It's not suicide if it captures any neighbour group.
+  opponent := g.stones[Opposite(color)]
+  neighbours := g.dilate(stone).And(opponent)
+  for !neighbours.Empty() {
+    var seed Bitboard
+    seed.Set(neighbours.First())
+    group := g.group(seed, opponent)
+    if g.liberties(group, empty).Empty() {
+      return false
+    }
+    neighbours = neighbours.AndNot(group)
+  }
+  return true
+}
+
+func (g *BitboardGoban) RemoveGroup(y, x int) int {
+  color := g.GetColor(y, x)
+  group := g.Group(y, x)
+  g.stones[color] = g.stones[color].AndNot(group)
+  return group.Count()
+}
@@ -0,0 +1,404 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Goban implementation with incremental group tracking.
+//
+// The stones of each group form a circular linked list, and every stone
+// points to the head of its group. The head keeps the group size and its
+// pseudo-liberties: the number of (stone, empty neighbour) pairs, plus the
+// sum and the sum of squares of the empty neighbours. A group has no
+// liberties when the count is zero, and is in atari when all its
+// pseudo-liberties are the same point, which happens exactly when
+// count * sum_sq == sum * sum.
+
+type chainPoint struct {
+  color Color
+  head, next int
+}
+
+type chainGroup struct {
+  size int
+  libs, lib_sum, lib_sum_sq int
+}
+
+type ChainGoban struct {
+  size_x, size_y int
+  points []chainPoint
+  groups []chainGroup
+  marks []uint32
+  generation uint32
+  scratch []int
+  stack Stack
+}
+
+func NewChainGoban(size_y, size_x int) *ChainGoban {
+  goban := new(ChainGoban)
+  goban.size_x = size_x
+  goban.size_y = size_y
+  goban.points = make([]chainPoint, size_y * size_x)
+  goban.groups = make([]chainGroup, size_y * size_x)
+  goban.marks = make([]uint32, size_y * size_x)
+  goban.generation = 1
+  goban.stack = NewSliceStack(size_x * size_y)
+  return goban
+}
+
+// Build a ChainGoban with the same stones as any other Goban.
+func NewChainGobanFrom(g Goban) *ChainGoban {
+  goban := NewChainGoban(g.SizeY(), g.SizeX())
+  iterateAll(g, func (y, x int) {
+    goban.SetColor(y, x, g.GetColor(y, x))
+  })
+  return goban
+}
+
+func (g *ChainGoban) Copy() Goban {
+  new_goban := new(ChainGoban)
+  new_goban.size_x = g.size_x
+  new_goban.size_y = g.size_y
+  new_goban.points = make([]chainPoint, len(g.points))
+  copy(new_goban.points, g.points)
+  new_goban.groups = make([]chainGroup, len(g.groups))
+  copy(new_goban.groups, g.groups)
+  new_goban.marks = make([]uint32, len(g.marks))
+  new_goban.generation = 1
+  return new_goban
+}
+
+// Overwrite the goban with the stones of other, which must have the same
+// size, reusing the existing buffers.
+func (g *ChainGoban) CopyFrom(other *ChainGoban) {
+  copy(g.points, other.points)
+  copy(g.groups, other.groups)
+}
+
+func (g *ChainGoban) SizeX() int {
+  return g.size_x
+}
+
+func (g *ChainGoban) SizeY() int {
+  return g.size_y
+}
+
+func (g *ChainGoban) GetColor(y, x int) Color {
+  return g.points[y * g.size_x + x].color
+}
+
+func (g *ChainGoban) SetColor(y, x int, color Color) {
+  p := y * g.size_x + x
+  old := g.points[p].color
+  if old == color {
+    return
+  }
+  switch old {
+  case INVALID:
+    g.setInvalid(p, false)
+  case BLACK, WHITE:
+    g.removeStone(p)
+  }
+  switch color {
+  case INVALID:
+    g.setInvalid(p, true)
+  case BLACK, WHITE:
+    g.placeStone(p, color)
+  }
+}
+
+func (g *ChainGoban) GetVisitorMarker() VisitorMarker {
+  return g
+}
+
+// Marks are cleared in O(1) by moving to a new generation.
+func (g *ChainGoban) ClearMarks() {
+  g.generation++
+  if g.generation == 0 {
+    for i := range g.marks {
+      g.marks[i] = 0
+    }
+    g.generation = 1
+  }
+}
+
+func (g *ChainGoban) SetMark(y, x int) {
+  g.marks[y * g.size_x + x] = g.generation
+}
+
+func (g *ChainGoban) IsMarked(y, x int) bool {
+  return g.marks[y * g.size_x + x] == g.generation
+}
+
+func (g *ChainGoban) GetStack() Stack {
+  return g.stack
+}
+
+func (g *ChainGoban) SetStack(stack Stack) {
+  g.stack = stack
+}
+
+// --------------------------
+// Group bookkeeping.
+
+// Neighbours on the board, skipping INVALID points.
+func (g *ChainGoban) neighbours(p int) ([4]int, int) {
+  var result [4]int
+  n := 0
+  y, x := p / g.size_x, p % g.size_x
+  if x > 0 && g.points[p - 1].color != INVALID {
+    result[n] = p - 1
+    n++
+  }
+  if x < g.size_x - 1 && g.points[p + 1].color != INVALID {
+    result[n] = p + 1
+    n++
+  }
+  if y > 0 && g.points[p - g.size_x].color != INVALID {
+    result[n] = p - g.size_x
+    n++
+  }
+  if y < g.size_y - 1 && g.points[p + g.size_x].color != INVALID {
+    result[n] = p + g.size_x
+    n++
+  }
+  return result, n
+}
+
+// Turn an empty point into a hole of an irregular board, or back.
+func (g *ChainGoban) setInvalid(p int, invalid bool) {
+  g.points[p] = chainPoint{EMPTY, p, p}
+  ns, n := g.neighbours(p)
+  for i := 0; i < n; i++ {
+    q := ns[i]
+    if g.points[q].color == EMPTY {
+      continue
+    }
+    if invalid {
+      g.removeLiberty(g.points[q].head, p)
+    } else {
+      g.addLiberty(g.points[q].head, p)
+    }
+  }
+  if invalid {
+    g.points[p].color = INVALID
+  }
+}
+
+func (g *ChainGoban) addLiberty(head, lib int) {
+  group := &g.groups[head]
+  group.libs++
+  group.lib_sum += lib
+  group.lib_sum_sq += lib * lib
+}
+
+func (g *ChainGoban) removeLiberty(head, lib int) {
+  group := &g.groups[head]
+  group.libs--
+  group.lib_sum -= lib
+  group.lib_sum_sq -= lib * lib
+}
+
+// Merge two groups, relabeling the smaller one.
+func (g *ChainGoban) merge(a, b int) int {
+  if g.groups[a].size < g.groups[b].size {
+    a, b = b, a
+  }
+  for s := b; ; {
+    g.points[s].head = a
+    s = g.points[s].next
+    if s == b {
+      break
+    }
+  }
+  g.points[a].next, g.points[b].next = g.points[b].next, g.points[a].next
+  ga, gb := &g.groups[a], &g.groups[b]
+  ga.size += gb.size
+  ga.libs += gb.libs
+  ga.lib_sum += gb.lib_sum
+  ga.lib_sum_sq += gb.lib_sum_sq
+  return a
+}
+
+func (g *ChainGoban) placeStone(p int, color Color) {
+  g.points[p] = chainPoint{color, p, p}
+  g.groups[p] = chainGroup{1, 0, 0, 0}
+  ns, n := g.neighbours(p)
+  for i := 0; i < n; i++ {
+    q := ns[i]
+    if g.points[q].color == EMPTY {
+      g.addLiberty(p, q)
+    } else {
+      g.removeLiberty(g.points[q].head, p)
+    }
+  }
+  head := p
+  for i := 0; i < n; i++ {
+    q := ns[i]
+    if g.points[q].color == color && g.points[q].head != head {
+      head = g.merge(head, g.points[q].head)
+    }
+  }
+}
+
+// Remove a single stone. The rest of its group may be split, so it's
+// rebuilt from scratch. Captures should use RemoveGroup instead.
+func (g *ChainGoban) removeStone(p int) {
+  head := g.points[p].head
+  g.scratch = g.scratch[:0]
+  for s := g.points[p].next; s != p; s = g.points[s].next {
+    g.scratch = append(g.scratch, s)
+  }
+  g.points[p] = chainPoint{EMPTY, p, p}
+  ns, n := g.neighbours(p)
+  for i := 0; i < n; i++ {
+    q := ns[i]
+    if g.points[q].color != EMPTY && g.points[q].head != head {
+      g.addLiberty(g.points[q].head, p)
+    }
+  }
+  for _, s := range g.scratch {
+    g.points[s].head = -1
+  }
+  for _, s := range g.scratch {
+    if g.points[s].head == -1 {
+      g.rebuildGroup(s)
+    }
+  }
+}
+
+// Rebuild the group of stone s, whose stones are marked with head -1.
+func (g *ChainGoban) rebuildGroup(s int) {
+  color := g.points[s].color
+  g.points[s].head = s
+  g.points[s].next = s
+  g.groups[s] = chainGroup{1, 0, 0, 0}
+  for t := s; ; {
+    ns, n := g.neighbours(t)
+    for i := 0; i < n; i++ {
+      q := ns[i]
+      switch {
+      case g.points[q].color == EMPTY:
+        g.addLiberty(s, q)
+      case g.points[q].color == color && g.points[q].head == -1:
+        // Insert after t, so the walk still visits it.
+        g.points[q].head = s
+        g.points[q].next = g.points[t].next
+        g.points[t].next = q
+        g.groups[s].size++
+      }
+    }
+    t = g.points[t].next
+    if t == s {
+      break
+    }
+  }
+}
+
+// --------------------------
+// GroupTracker implementation.
+
+func (g *ChainGoban) HasLiberties(y, x int) bool {
+  return g.groups[g.points[y * g.size_x + x].head].libs > 0
+}
+
+func (g *ChainGoban) inAtari(head int) (int, bool) {
+  group := &g.groups[head]
+  if group.libs == 0 ||
+     group.libs * group.lib_sum_sq != group.lib_sum * group.lib_sum {
+    return 0, false
+  }
+  return group.lib_sum / group.libs, true
+}
+
+func (g *ChainGoban) InAtari(y, x int) bool {
+  _, atari := g.inAtari(g.points[y * g.size_x + x].head)
+  return atari
+}
+
+// A move is suicide if it has no empty neighbours, doesn't capture, and
+// all friendly neighbour groups have this point as their last liberty.
+func (g *ChainGoban) Suicide(y, x int, color Color) bool {
+  p := y * g.size_x + x
+  ns, n := g.neighbours(p)
+  for i := 0; i < n; i++ {
+    q := ns[i]
+    if g.points[q].color == EMPTY {
+      return false
+    }
+    lib, atari := g.inAtari(g.points[q].head)
+    if g.points[q].color == color {
+      if !atari || lib != p {
+        return false
+      }
+    } else if atari {
+      return false
+    }
+  }
+  return true
+}
+
+func (g *ChainGoban) RemoveGroup(y, x int) int {
+  head := g.points[y * g.size_x + x].head
+  size := g.groups[head].size
+  for s := head; ; {
+    g.points[s].color = EMPTY
+    s = g.points[s].next
+    if s == head {
+      break
+    }
+  }
+  for s := head; ; {
+    next := g.points[s].next
+    ns, n := g.neighbours(s)
+    for i := 0; i < n; i++ {
+      q := ns[i]
+      if g.points[q].color != EMPTY {
+        g.addLiberty(g.points[q].head, s)
+      }
+    }
+    g.points[s] = chainPoint{EMPTY, s, s}
+    s = next
+    if s == head {
+      break
+    }
+  }
+  return size
+}
+
+func (g *ChainGoban) LibertiesUpTo(y, x, limit int) int {
+  g.ClearMarks()
+  head := g.points[y * g.size_x + x].head
+  liberties := 0
+  for s := head; ; {
+    ns, n := g.neighbours(s)
+    for i := 0; i < n; i++ {
+      q := ns[i]
+      if g.points[q].color == EMPTY && g.marks[q] != g.generation {
+        g.marks[q] = g.generation
+        liberties++
+        if liberties >= limit {
+          return liberties
+        }
+      }
+    }
+    s = g.points[s].next
+    if s == head {
+      break
+    }
+  }
+  return liberties
+}
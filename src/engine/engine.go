@@ -28,17 +28,52 @@ type GameState struct {
   goban Goban
   komi float32
   captured_white, captured_black int
+  moves int
+  handicap int
+  komi_mode KomiMode
+  rules Ruleset
+  policy PolicyKind
+  // Last move played, used by the playout policies.
+  last Position
+  has_last bool
+  // Moves played by playRandomGame, and the winner if it was stopped
+  // early, or EMPTY.
+  sequence []playoutMove
+  early_winner Color
+  // Point where ko_color can't play because of the simple ko rule.
+  ko Position
+  ko_color Color
+  has_ko bool
+  // Hashes of the previous positions, used by the superko rules.
+  history []PositionHash
+  // Game record, and the result of the last search.
+  setup, record []MoveRecord
+  search SearchInfo
+  // Scratch buffers for scoring.
+  visited []bool
+  seki []bool
+  // Empty points in any order, and the index of each point in the list,
+  // or -1. Kept up to date by Play and Setup after trackEmpty, and built
+  // again when the board is cleared or the region changes.
+  empty []int
+  empty_index []int
+  // Points where the search can play, or nil for the whole board, and
+  // whether the playouts are restricted to them too.
+  region []bool
+  region_playouts bool
 }
 
 // --------------------------
 // Iterators over the Goban.
 
-func encode(y, x int) int {
-  return y * 256 + x
+// Positions are encoded as a single int, row by row, so boards can have
+// any dimensions.
+func encode(g Goban, y, x int) int {
+  return y * g.SizeX() + x
 }
 
-func decode(code int) (int, int) {
-  return code >> 8, code & 255
+func decode(g Goban, code int) (int, int) {
+  return code / g.SizeX(), code % g.SizeX()
 }
 
 func valid(y, x, maxY, maxX int) bool {
@@ -48,10 +83,12 @@ func valid(y, x, maxY, maxX int) bool {
 var dx = []int{1, -1, 0, 0}
 var dy = []int{0, 0, 1, -1}
 
+// Points marked as INVALID are holes in irregular boards, and are never
+// visited.
 func iterateNeighbours(g Goban, y, x int, callback func(y, x int)) {
   for i := 0; i < 4; i++ {
     nx, ny := x + dx[i], y + dy[i]
-    if valid(ny, nx, g.SizeY(), g.SizeX()) {
+    if valid(ny, nx, g.SizeY(), g.SizeX()) && g.GetColor(ny, nx) != INVALID {
       callback(ny, nx)
     }
   }
@@ -63,7 +100,7 @@ var diagy = []int{1, 1, -1, -1}
 func iterateDiagonals(g Goban, y, x int, callback func(y, x int)) {
   for i := 0; i < 4; i++ {
     nx, ny := x + diagx[i], y + diagy[i]
-    if valid(ny, nx, g.SizeY(), g.SizeX()) {
+    if valid(ny, nx, g.SizeY(), g.SizeX()) && g.GetColor(ny, nx) != INVALID {
       callback(ny, nx)
     }
   }
@@ -80,14 +117,14 @@ func iterateGroup(g Goban, y, x int,
   marks.ClearMarks()
   marks.SetMark(y, x)
   group_callback(y, x)
-  next.Push(encode(y, x))
+  next.Push(encode(g, y, x))
   for !next.Empty() {
-    y, x := decode(next.Pop())
+    y, x := decode(g, next.Pop())
     iterateNeighbours(g, y, x, func (ny, nx int) {
       if !marks.IsMarked(ny, nx) {
         if g.GetColor(ny, nx) == color {
           group_callback(ny, nx)
-          next.Push(encode(ny, nx))
+          next.Push(encode(g, ny, nx))
         } else {
           border_callback(ny, nx)
         }
@@ -123,6 +160,31 @@ func CountLiberties(g Goban, y, x int) int {
   return liberties
 }
 
+// Liberties of the group at y, x, stopping the count at limit.
+func countLiberties(g Goban, y, x, limit int) int {
+  if counter, ok := g.(LibertyCounter); ok {
+    return counter.LibertiesUpTo(y, x, limit)
+  }
+  if liberties := CountLiberties(g, y, x); liberties < limit {
+    return liberties
+  }
+  return limit
+}
+
+func hasLiberties(g Goban, y, x int) bool {
+  if tracker, ok := g.(GroupTracker); ok {
+    return tracker.HasLiberties(y, x)
+  }
+  return CountLiberties(g, y, x) > 0
+}
+
+func inAtari(g Goban, y, x int) bool {
+  if tracker, ok := g.(GroupTracker); ok {
+    return tracker.InAtari(y, x)
+  }
+  return CountLiberties(g, y, x) == 1
+}
+
 func Opposite(color Color) Color {
   if color == BLACK {
     return WHITE
@@ -131,6 +193,9 @@ func Opposite(color Color) Color {
 }
 
 func Suicide(g Goban, y, x int, color Color) bool {
+  if tracker, ok := g.(GroupTracker); ok {
+    return tracker.Suicide(y, x, color)
+  }
   // It's not suicide if you have an empty cell next to you.
   liberties := 0
   iterateNeighbours(g, y, x, func (ny, nx int) {
@@ -163,16 +228,26 @@ func Suicide(g Goban, y, x int, color Color) bool {
 
 func ValidMoves(g Goban, color Color, callback func (y, x int)) {
   iterateAllColor(g, EMPTY, func (y, x int) {
-    if !Suicide(g, y, x, color) {
-      eye_color, ok := SinglePointEye(g, y, x)
-      if !ok || eye_color != color {
-        callback(y, x)
-      }
+    if validMove(g, y, x, color) {
+      callback(y, x)
     }
   })
 }
 
+// An empty point is a valid move if it's not suicide, doesn't fill one
+// of our own eyes and doesn't break a seki.
+func validMove(g Goban, y, x int, color Color) bool {
+  if Suicide(g, y, x, color) {
+    return false
+  }
+  eye_color, ok := SinglePointEye(g, y, x)
+  return (!ok || eye_color != color) && !breaksSeki(g, y, x, color)
+}
+
 func RemoveGroup(g Goban, y, x int) int {
+  if tracker, ok := g.(GroupTracker); ok {
+    return tracker.RemoveGroup(y, x)
+  }
   captured := 0
   iterateGroup(g, y, x, func (ny, nx int) {
     captured++
@@ -191,34 +266,54 @@ func addCaptured(state *GameState, color Color, captured int) {
 }
 
 func Play(state *GameState, y, x int, color Color) {
+  state.moves++
+  state.has_ko = false
   state.goban.SetColor(y, x, color)
+  removeEmpty(state, encode(state.goban, y, x))
+  state.last = Position{y, x}
+  state.has_last = true
+  total := 0
+  var last Position
   iterateNeighbours(state.goban, y, x, func (ny, nx int) {
     if state.goban.GetColor(ny, nx) == Opposite(color) {
-      if CountLiberties(state.goban, ny, nx) == 0 {
+      if !hasLiberties(state.goban, ny, nx) {
+        addEmptyGroup(state, ny, nx)
         captured := RemoveGroup(state.goban, ny, nx)
         addCaptured(state, color, captured)
+        total += captured
+        last = Position{ny, nx}
       }
     }
   })
+  if total == 1 && isKoCapture(state.goban, y, x, color) {
+    state.has_ko = true
+    state.ko = last
+    state.ko_color = Opposite(color)
+  }
+  if total == 0 && state.ruleset().SuicideAllowed() &&
+     !hasLiberties(state.goban, y, x) {
+    addEmptyGroup(state, y, x)
+    captured := RemoveGroup(state.goban, y, x)
+    addCaptured(state, Opposite(color), captured)
+  }
 }
 
-func SinglePointEye(g Goban, y, x int) (Color, bool) {
-  histogram := make([]int, 4)
+// A single stone capture is a ko if the capturing stone is alone and
+// has the captured point as its only liberty.
+func isKoCapture(g Goban, y, x int, color Color) bool {
+  alone := true
   iterateNeighbours(g, y, x, func (ny, nx int) {
-    histogram[g.GetColor(ny, nx)]++
-  })
-  if histogram[EMPTY] > 0 || (histogram[BLACK] > 0 && histogram[WHITE] > 0) {
-    return EMPTY, false
-  }
-  color := Color(WHITE)
-  if histogram[BLACK] > 0 {
-    color = BLACK
-  }
-  diagonals := make([]int, 4)
-  iterateDiagonals(g, y, x, func (ny, nx int) {
-    diagonals[g.GetColor(ny, nx)]++
+    if g.GetColor(ny, nx) == color {
+      alone = false
+    }
   })
-  return color, diagonals[color] > diagonals[Opposite(color)]
+  return alone && inAtari(g, y, x)
+}
+
+// A real eye of a single point. See ClassifyEye.
+func SinglePointEye(g Goban, y, x int) (Color, bool) {
+  color, kind := ClassifyEye(g, y, x)
+  return color, kind == REAL_EYE
 }
 
 type Position struct {
@@ -229,9 +324,13 @@ var dump bool = false
 
 func GetMoveList(g Goban, color Color) []Position {
   moves := make([]Position, 0, g.SizeY() * g.SizeX())
-  ValidMoves(g, color, func (y, x int) {
-    moves = append(moves, Position{y, x})
-  })
+  for j := 0; j < g.SizeY(); j++ {
+    for i := 0; i < g.SizeX(); i++ {
+      if g.GetColor(j, i) == EMPTY && validMove(g, j, i, color) {
+        moves = append(moves, Position{j, i})
+      }
+    }
+  }
   if (dump) {
     fmt.Fprintf(os.Stderr, "# %v\n", moves)
   }
@@ -247,44 +346,29 @@ func GetRandomMove(g Goban, color Color) (Position, bool) {
   return moves[rand.Intn(len(moves))], true
 }
 
-func dumpState(state *GameState) {
-  conv := map[Color] string {
-    EMPTY : ".",
-    BLACK : "x",
-    WHITE : "o",
-  }
-  fmt.Printf("--- cap white: %d, cap black %d\n",
-             state.captured_white, state.captured_black)
-  for j := 0; j < state.goban.SizeY(); j++ {
-    for i := 0; i < state.goban.SizeX(); i++ {
-      fmt.Printf(conv[state.goban.GetColor(j, i)])
-    }
-    fmt.Printf("\n")
-  }
-  fmt.Printf("\n")
-}
-
-func dumpGoban(goban Goban) {
-  conv := map[Color] string {
-    EMPTY : ".",
-    BLACK : "x",
-    WHITE : "o",
-  }
-  for j := 0; j < goban.SizeY(); j++ {
-    for i := 0; i < goban.SizeX(); i++ {
-      fmt.Fprintf(os.Stderr, conv[goban.GetColor(j, i)])
-    }
-    fmt.Fprintf(os.Stderr, "\n")
-  }
-  fmt.Fprintf(os.Stderr, "\n")
-}
-
 func PlayRandomGame(state *GameState, color Color) {
-  limit := state.goban.SizeY() * state.goban.SizeX() * 3
+  playRandomGame(state, color, newPolicy(state.policy, state),
+                 state.captured_white - state.captured_black)
+}
+
+// Play a game with moves chosen by the policy, keeping track of the empty
+// points so that moves can be sampled without scanning the board. The
+// mercy rule counts captures from the given difference, white stones
+// captured minus black ones, usually the one of the search root.
+func playRandomGame(state *GameState, color Color, policy Policy,
+                    captures int) {
+  if state.empty_index == nil {
+    trackEmpty(state)
+  }
+  state.early_winner = EMPTY
+  area := state.goban.SizeY() * state.goban.SizeX()
+  limit := area * 3
   for i := 0; i < limit; i++ {
-    move, ok := GetRandomMove(state.goban, color)
+    move, ok := policy.Move(state, color)
     if !ok {
-      _, ok := GetRandomMove(state.goban, Opposite(color))
+      state.has_last = false
+      state.sequence = append(state.sequence, playoutMove{color, area})
+      _, ok := policy.Move(state, Opposite(color))
       if !ok {
         return
       }
@@ -292,30 +376,178 @@ func PlayRandomGame(state *GameState, color Color) {
       continue
     }
     Play(state, move.y, move.x, color)
-    //dumpState(state)
+    point := encode(state.goban, move.y, move.x)
+    state.sequence = append(state.sequence, playoutMove{color, point})
     color = Opposite(color)
+    if winner := mercyWinner(state, captures); winner != EMPTY {
+      state.early_winner = winner
+      return
+    }
+    if len(state.empty) <= area / eyes_check_ratio && onlyEyes(state) {
+      return
+    }
+  }
+}
+
+// --------------------------
+// Early playout termination.
+
+// A playout stops when one side has captured more than 1 / mercy_ratio
+// of the board area over the other side since the playout started, and
+// is won by that side.
+const mercy_ratio = 4
+
+// Check for the end of the game only when there are few empty points.
+const eyes_check_ratio = 4
+
+func mercyWinner(state *GameState, captures int) Color {
+  area := state.goban.SizeY() * state.goban.SizeX()
+  difference := state.captured_white - state.captured_black - captures
+  switch {
+  case difference > area / mercy_ratio:
+    return BLACK
+  case -difference > area / mercy_ratio:
+    return WHITE
+  }
+  return EMPTY
+}
+
+// The game is over when every empty point is an eye of a group that
+// isn't in atari, since neither side can move without suicide or
+// filling its own eyes.
+func onlyEyes(state *GameState) bool {
+  g := state.goban
+  for _, p := range state.empty {
+    y, x := decode(g, p)
+    if _, ok := SinglePointEye(g, y, x); !ok {
+      return false
+    }
+    atari := false
+    iterateNeighbours(g, y, x, func (ny, nx int) {
+      if !atari && inAtari(g, ny, nx) {
+        atari = true
+      }
+    })
+    if atari {
+      return false
+    }
+  }
+  return true
+}
+
+// --------------------------
+// Incremental list of empty points.
+
+// Build the list of empty points from the goban, reusing the buffers.
+func trackEmpty(state *GameState) {
+  size := state.goban.SizeY() * state.goban.SizeX()
+  if len(state.empty_index) != size {
+    state.empty = make([]int, 0, size)
+    state.empty_index = make([]int, size)
+  }
+  state.empty = state.empty[:0]
+  for p := 0; p < size; p++ {
+    state.empty_index[p] = -1
+    y, x := decode(state.goban, p)
+    if state.goban.GetColor(y, x) == EMPTY && state.inPlayoutRegion(p) {
+      state.empty_index[p] = len(state.empty)
+      state.empty = append(state.empty, p)
+    }
+  }
+}
+
+// Build the list again if it's being tracked, after a change that Play
+// doesn't follow.
+func retrackEmpty(state *GameState) {
+  if state.empty_index != nil {
+    trackEmpty(state)
+  }
+}
+
+func addEmpty(state *GameState, p int) {
+  if state.empty_index == nil || state.empty_index[p] >= 0 ||
+     !state.inPlayoutRegion(p) {
+    return
+  }
+  state.empty_index[p] = len(state.empty)
+  state.empty = append(state.empty, p)
+}
+
+// Remove a point, moving the last one of the list into its place.
+func removeEmpty(state *GameState, p int) {
+  if state.empty_index == nil || state.empty_index[p] < 0 {
+    return
+  }
+  last := len(state.empty) - 1
+  swapEmpty(state, state.empty_index[p], last)
+  state.empty = state.empty[:last]
+  state.empty_index[p] = -1
+}
+
+func swapEmpty(state *GameState, i, j int) {
+  state.empty[i], state.empty[j] = state.empty[j], state.empty[i]
+  state.empty_index[state.empty[i]] = i
+  state.empty_index[state.empty[j]] = j
+}
+
+// Add the stones of a group that is about to be captured.
+func addEmptyGroup(state *GameState, y, x int) {
+  if state.empty_index == nil {
+    return
   }
+  iterateGroup(state.goban, y, x, func (ny, nx int) {
+    addEmpty(state, encode(state.goban, ny, nx))
+  }, func (ny, nx int) {})
 }
 
+// Pick a uniformly random valid move by rejection sampling. Rejected
+// points are swapped past the end of the candidates, so every point is
+// tried at most once.
+func randomMove(state *GameState, color Color) (Position, bool) {
+  for n := len(state.empty); n > 0; n-- {
+    i := rand.Intn(n)
+    y, x := decode(state.goban, state.empty[i])
+    if validMove(state.goban, y, x, color) {
+      return Position{y, x}, true
+    }
+    swapEmpty(state, i, n - 1)
+  }
+  return Position{0, 0}, false
+}
+
+// Stones plus eyes: real single point eyes and small eye spaces.
 func EstimatePoints(g Goban) (black, white int) {
   points := make([]int, 4)
+  space := make([]Position, 0, max_eye_space)
   iterateAll(g, func (y, x int) {
     color := g.GetColor(y, x)
-    if color == EMPTY {
-      eye_color, ok := SinglePointEye(g, y, x)
-      if ok {
-        points[eye_color] += 1
-      }
-    } else {
+    if color != EMPTY {
       points[color] += 1
+      return
+    }
+    var eye_color Color
+    var ok bool
+    eye_color, space, ok = appendEyeSpace(g, y, x, space)
+    if ok && len(space) == 1 {
+      eye_color, ok = SinglePointEye(g, y, x)
+    }
+    if ok {
+      points[eye_color] += 1
     }
   })
   return points[BLACK], points[WHITE]
 }
 
 func Winner(state *GameState) Color {
-  black, white := EstimatePoints(state.goban)
-  if float32(black) > float32(white) + state.komi {
+  return winnerWithKomi(state, state.komi)
+}
+
+func winnerWithKomi(state *GameState, komi float32) Color {
+  if state.early_winner != EMPTY {
+    return state.early_winner
+  }
+  black, white := state.ruleset().Score(state)
+  if black > white + komi {
     return BLACK
   }
   return WHITE
@@ -324,31 +556,83 @@ func Winner(state *GameState) Color {
 type MoveStats struct {
   win, total int
   rate float64
+  true_win int
 }
 
 func copyState(state *GameState) *GameState {
+  return copyStateWithGoban(state, state.goban.Copy())
+}
+
+func copyStateWithGoban(state *GameState, goban Goban) *GameState {
   new_state := new(GameState)
-  new_state.komi = state.komi
-  new_state.captured_white = state.captured_white
-  new_state.captured_black = state.captured_black
-  new_state.goban = state.goban.Copy()
+  loadState(new_state, state, goban)
   return new_state
 }
 
+// Overwrite dst with the position of state, played on goban. The history
+// and game record are not copied, and dst keeps its scratch buffers.
+func loadState(dst *GameState, state *GameState, goban Goban) {
+  dst.komi = state.komi
+  dst.captured_white = state.captured_white
+  dst.captured_black = state.captured_black
+  dst.moves = state.moves
+  dst.handicap = state.handicap
+  dst.komi_mode = state.komi_mode
+  dst.rules = state.rules
+  dst.policy = state.policy
+  dst.last = state.last
+  dst.has_last = state.has_last
+  dst.ko = state.ko
+  dst.ko_color = state.ko_color
+  dst.has_ko = state.has_ko
+  dst.region = state.region
+  dst.region_playouts = state.region_playouts
+  dst.goban = goban
+}
+
+// Initial stats of the moves in each search worker. Running out of a
+// ladder that doesn't work starts with a low win rate, and starting a
+// ladder that works with a high one.
+func movePriors(state *GameState, moves []Position, color Color) []MoveStats {
+  g := state.goban
+  reader := NewLadderReader(g.SizeY(), g.SizeX())
+  priors := make([]MoveStats, len(moves))
+  for i, move := range moves {
+    win, total := 1, 2
+    iterateNeighbours(g, move.y, move.x, func (y, x int) {
+      switch g.GetColor(y, x) {
+      case color:
+        if inAtari(g, y, x) && reader.CapturedAfter(g, move, color, y, x) {
+          win, total = 1, 10
+        }
+      case Opposite(color):
+        if CountLiberties(g, y, x) == 2 &&
+           reader.CapturedAfter(g, move, color, y, x) {
+          win, total = 9, 10
+        }
+      }
+    })
+    priors[i] = MoveStats{win: win, total: total,
+                          rate: float64(win) / float64(total)}
+  }
+  return priors
+}
+
+// The win field is measured with the search komi, true_win with the
+// real komi.
 type GameResult struct {
   move int
-  win bool
+  win, true_win bool
+  // Stopped by the mercy rule.
+  mercy bool
 }
 
-func launchSinglePlay(state *GameState, moves []Position,
-                      color Color, ch chan GameResult) {
-  stack := NewSliceStack(state.goban.SizeX() * state.goban.SizeY())
+func launchSinglePlay(state *GameState, moves []Position, priors []MoveStats,
+                      replies *ReplyTable, color Color, ch chan GameResult) {
+  playout := newPlayout(state, replies)
   stats := make([]MoveStats, len(moves))
-  for i := 0; i < len(stats); i++ {
-    stats[i].total = 2
-    stats[i].win = 1
-    stats[i].rate = 0.5
-  }
+  copy(stats, priors)
+  komi := newKomiAdjuster(state)
   for {
     var total float64 = 0.0
     for i := 0; i < len(stats); i++ {
@@ -363,11 +647,15 @@ func launchSinglePlay(state *GameState, moves []Position,
       }
       p -= stats[i].rate
     }
-    copy_state := copyState(state)
-    copy_state.goban.SetColor(moves[result.move].y, moves[result.move].x, color)
-    copy_state.goban.SetStack(stack)
-    PlayRandomGame(copy_state, color)
-    result.win = Winner(copy_state) == color
+    copy_state := playout.Run(moves[result.move], color)
+    winner := winnerWithKomi(copy_state, komi.komi)
+    replies.Update(copy_state.sequence, winner)
+    result.win = winner == color
+    result.true_win = winnerWithKomi(copy_state, state.komi) == color
+    result.mercy = copy_state.early_winner != EMPTY
+    if state.komi_mode == VALUE_KOMI {
+      komi.Update(result.win, color)
+    }
     stats[result.move].total += 1
     if result.win {
       stats[result.move].win += 1
@@ -380,20 +668,27 @@ func launchSinglePlay(state *GameState, moves []Position,
 
 func GetBestMove(state *GameState, color Color, seconds int) (
     y, x int, pass bool) {
+  state.search = SearchInfo{}
   dump = true
-  dumpGoban(state.goban)
-  moves := GetMoveList(state.goban, color)
+  fmt.Fprint(os.Stderr, RenderGoban(state.goban))
+  moves := legalMoves(state, GetMoveList(state.goban, color), color)
+  moves = settledMoves(state.goban, moves)
+  moves = regionMoves(state, moves)
+  moves = symmetricMoves(state, moves)
   dump = false
   if len(moves) == 0 {
     return 0, 0, true
   }
   stats := make([]MoveStats, len(moves))
+  priors := movePriors(state, moves, color)
+  replies := NewReplyTable(state.goban.SizeY(), state.goban.SizeX())
   processors := runtime.NumCPU()
   runtime.GOMAXPROCS(processors)
   ch := make(chan GameResult, processors)
   for i := 0; i < processors; i++ {
-    go launchSinglePlay(state, moves, color, ch)
+    go launchSinglePlay(state, moves, priors, replies, color, ch)
   }
+  mercy := 0
   timeout := make(chan bool)
   go func() {
     time.Sleep(time.Duration(seconds) * time.Second)
@@ -409,13 +704,20 @@ func GetBestMove(state *GameState, color Color, seconds int) (
         if result.win {
           stats[result.move].win += 1
         }
+        if result.true_win {
+          stats[result.move].true_win += 1
+        }
+        if result.mercy {
+          mercy++
+        }
       }
     }
   }()
+  // Report the win rates with the real komi.
   for i := 0; i < len(moves); i++ {
     fmt.Fprintf(os.Stderr, "# move %d %d : %d / %d = %f\n",
-                moves[i].y, moves[i].x, stats[i].win, stats[i].total,
-                float32(stats[i].win) / float32(stats[i].total))
+                moves[i].y, moves[i].x, stats[i].true_win, stats[i].total,
+                float32(stats[i].true_win) / float32(stats[i].total))
   }
   best := 0
   plays := 0
@@ -426,19 +728,27 @@ func GetBestMove(state *GameState, color Color, seconds int) (
     plays += stats[i].total
   }
   fmt.Fprintf(os.Stderr,"# %f plays/s\n", float32(plays) / float32(seconds))
+  fmt.Fprintf(os.Stderr,"# %d plays stopped by the mercy rule\n", mercy)
   fmt.Fprintf(os.Stderr,"# %d stacks\n", slicestacks)
+  state.search = SearchInfo{color, float32(stats[best].true_win) /
+                            float32(stats[best].total), plays, true}
   return moves[best].y, moves[best].x, false
 }
 
 func NewEmptyGameState(y, x int) *GameState {
   goban := NewArrayGoban(y, x)
   FromString(goban, strings.Repeat(".", y * x))
-  return &GameState{goban, 6.5, 0, 0}
+  return &GameState{goban: goban, komi: 6.5}
 }
 
 func NewGameState(y, x int, komi float32, init string) *GameState {
-  goban := NewSliceGoban(y, x)
+  return NewGameStateWithGoban(DefaultGoban, y, x, komi, init)
+}
+
+func NewGameStateWithGoban(kind GobanKind, y, x int, komi float32,
+                           init string) *GameState {
+  goban := NewGoban(kind, y, x)
   FromString(goban, init)
-  return &GameState{goban, komi, 0, 0}
+  return &GameState{goban: goban, komi: komi}
 }
 
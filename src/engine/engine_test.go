@@ -118,8 +118,11 @@ func TestValidMoves(t *testing.T) {
   expected_white := []Position {
     {0, 0},
   }
+  // The corner at 2, 3 is a real eye for black, which black doesn't fill.
+  // Its only diagonal, 1, 2, can't make it false: white playing there
+  // would be suicide.
   expected_black := []Position {
-    {0, 0}, {1, 2}, {2, 0}, {2, 3},
+    {0, 0}, {1, 2}, {2, 0},
   }
   actual_white := GetMoveList(goban, WHITE)
   actual_black := GetMoveList(goban, BLACK)
@@ -139,6 +142,7 @@ func ToString(g Goban) string {
     EMPTY : ".",
     BLACK : "x",
     WHITE : "o",
+    INVALID : "#",
   }
   for j := 0; j < g.SizeY(); j++ {
     for i := 0; i < g.SizeX(); i++ {
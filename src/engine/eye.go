@@ -0,0 +1,175 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Eye classification.
+
+const (
+  NO_EYE = iota
+  FALSE_EYE
+  REAL_EYE
+)
+
+type EyeKind int
+
+// Classify an empty point surrounded by stones of a single color. The
+// diagonals decide if it's a real eye: an opponent stone counts as a bad
+// diagonal, and an empty point where the opponent can play counts as half
+// of one. In the center an eye is false with two bad diagonals, and on
+// the edge or in the corner with just one.
+func ClassifyEye(g Goban, y, x int) (Color, EyeKind) {
+  var neighbours [4]int
+  iterateNeighbours(g, y, x, func (ny, nx int) {
+    neighbours[g.GetColor(ny, nx)]++
+  })
+  if neighbours[EMPTY] > 0 || neighbours[BLACK] > 0 && neighbours[WHITE] > 0 ||
+     neighbours[BLACK] == 0 && neighbours[WHITE] == 0 {
+    return EMPTY, NO_EYE
+  }
+  color := Color(WHITE)
+  if neighbours[BLACK] > 0 {
+    color = BLACK
+  }
+  // Count in halves of a bad diagonal.
+  on_board, bad := 0, 0
+  iterateDiagonals(g, y, x, func (ny, nx int) {
+    on_board++
+    switch g.GetColor(ny, nx) {
+    case Opposite(color):
+      bad += 2
+    case EMPTY:
+      if !Suicide(g, ny, nx, Opposite(color)) {
+        bad += 1
+      }
+    }
+  })
+  limit := 2
+  if on_board == 4 {
+    limit = 4
+  }
+  if bad >= limit {
+    return color, FALSE_EYE
+  }
+  return color, REAL_EYE
+}
+
+// --------------------------
+// Eye spaces.
+
+// Largest eye space considered, which fits the rabbity six.
+const max_eye_space = 6
+
+// Collect the empty region containing y, x in space, if it has at most
+// max_eye_space points and only touches stones of a single color.
+func appendEyeSpace(g Goban, y, x int, space []Position) (
+    Color, []Position, bool) {
+  space = append(space[:0], Position{y, x})
+  color := Color(EMPTY)
+  ok := true
+  for i := 0; ok && i < len(space); i++ {
+    iterateNeighbours(g, space[i].y, space[i].x, func (ny, nx int) {
+      switch stone := g.GetColor(ny, nx); {
+      case !ok:
+      case stone == EMPTY:
+        p := Position{ny, nx}
+        for _, q := range space {
+          if q == p {
+            return
+          }
+        }
+        if len(space) == max_eye_space {
+          ok = false
+          return
+        }
+        space = append(space, p)
+      case color == EMPTY:
+        color = stone
+      case color != stone:
+        ok = false
+      }
+    })
+  }
+  return color, space, ok && color != EMPTY
+}
+
+// The eye space containing y, x, and the color surrounding it.
+func EyeSpace(g Goban, y, x int) (Color, []Position, bool) {
+  return appendEyeSpace(g, y, x, make([]Position, 0, max_eye_space))
+}
+
+// Eye spaces that are a single eye after the opponent plays at the
+// vital point, identified by the sorted number of neighbours of each
+// point inside the space.
+var nakade_shapes = [][]int{
+  {1, 1, 2},           // Straight and bent three.
+  {1, 1, 1, 3},        // Pyramid four.
+  {1, 1, 1, 1, 4},     // Crossed five.
+  {1, 2, 2, 2, 3},     // Bulky five.
+  {1, 1, 2, 2, 2, 4},  // Rabbity six.
+}
+
+// The vital point of a nakade shape, which is the point with the most
+// neighbours in the space.
+func VitalPoint(space []Position) (Position, bool) {
+  if len(space) > max_eye_space {
+    return Position{0, 0}, false
+  }
+  var degrees, sorted [max_eye_space]int
+  vital := 0
+  for i, p := range space {
+    for _, q := range space {
+      if abs(p.y - q.y) + abs(p.x - q.x) == 1 {
+        degrees[i]++
+      }
+    }
+    if degrees[i] > degrees[vital] {
+      vital = i
+    }
+  }
+  // Insertion sort of the degrees.
+  n := len(space)
+  for i := 0; i < n; i++ {
+    j := i
+    for ; j > 0 && sorted[j - 1] > degrees[i]; j-- {
+      sorted[j] = sorted[j - 1]
+    }
+    sorted[j] = degrees[i]
+  }
+  for _, shape := range nakade_shapes {
+    if len(shape) != n {
+      continue
+    }
+    match := true
+    for i := range shape {
+      if shape[i] != sorted[i] {
+        match = false
+      }
+    }
+    if match {
+      return space[vital], true
+    }
+  }
+  return Position{0, 0}, false
+}
+
+func abs(a int) int {
+  if a < 0 {
+    return -a
+  }
+  return a
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestClassifyEye(c *C) {
+  cases := []struct {
+    board string
+    y, x int
+    color Color
+    kind EyeKind
+  }{
+    // Center, with one and two bad diagonals.
+    {"xxx" + "x.x" + "xxo", 1, 1, BLACK, REAL_EYE},
+    {"oxx" + "x.x" + "xxo", 1, 1, BLACK, FALSE_EYE},
+    // Edge, where a single bad diagonal is enough.
+    {"x.x" + "xxx" + "...", 0, 1, BLACK, REAL_EYE},
+    {"x.x" + "oxx" + "...", 0, 1, BLACK, FALSE_EYE},
+    // Corner, including an empty diagonal where white can play.
+    {".x." + "xx." + "...", 0, 0, BLACK, REAL_EYE},
+    {".x." + "x.." + "...", 0, 0, BLACK, REAL_EYE},
+    {".o." + "ox." + "...", 0, 0, WHITE, FALSE_EYE},
+    // Not surrounded by a single color.
+    {".x." + "o.." + "...", 0, 0, EMPTY, NO_EYE},
+    {".x." + "..." + "...", 0, 0, EMPTY, NO_EYE},
+  }
+  for _, test := range cases {
+    goban := NewSliceGoban(3, 3)
+    FromString(goban, test.board)
+    color, kind := ClassifyEye(goban, test.y, test.x)
+    c.Check(color, Equals, test.color, Commentf("%s", test.board))
+    c.Check(kind, Equals, test.kind, Commentf("%s", test.board))
+  }
+}
+
+func (s *S) TestVitalPoint(c *C) {
+  cases := []struct {
+    space []Position
+    vital Position
+    nakade bool
+  }{
+    {[]Position{{0, 0}, {0, 1}, {0, 2}}, Position{0, 1}, true},
+    {[]Position{{0, 0}, {0, 1}, {1, 1}}, Position{0, 1}, true},
+    {[]Position{{0, 0}, {0, 1}, {0, 2}, {1, 1}}, Position{0, 1}, true},
+    {[]Position{{1, 1}, {0, 1}, {1, 0}, {1, 2}, {2, 1}}, Position{1, 1}, true},
+    {[]Position{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}}, Position{1, 0}, true},
+    {[]Position{{0, 1}, {1, 0}, {1, 1}, {1, 2}, {2, 1}, {2, 2}},
+     Position{1, 1}, true},
+    // The square four is dead without a vital point, and the straight
+    // four is alive.
+    {[]Position{{0, 0}, {0, 1}, {1, 0}, {1, 1}}, Position{0, 0}, false},
+    {[]Position{{0, 0}, {0, 1}, {0, 2}, {0, 3}}, Position{0, 0}, false},
+  }
+  for _, test := range cases {
+    vital, ok := VitalPoint(test.space)
+    c.Check(ok, Equals, test.nakade, Commentf("%v", test.space))
+    if ok {
+      c.Check(vital, Equals, test.vital, Commentf("%v", test.space))
+    }
+  }
+}
+
+func (s *S) TestEyeSpace(c *C) {
+  goban := NewSliceGoban(4, 5)
+  FromString(goban, "...o." +
+                    "oooo." +
+                    "....." +
+                    ".....")
+  color, space, ok := EyeSpace(goban, 0, 1)
+  c.Check(ok, Equals, true)
+  c.Check(color, Equals, Color(WHITE))
+  c.Check(len(space), Equals, 3)
+  vital, ok := VitalPoint(space)
+  c.Check(ok, Equals, true)
+  c.Check(vital, Equals, Position{0, 1})
+  // The empty region on the right is too big.
+  _, _, ok = EyeSpace(goban, 0, 4)
+  c.Check(ok, Equals, false)
+}
+
+func (s *S) TestEstimatePointsEyeSpace(c *C) {
+  goban := NewSliceGoban(2, 4)
+  FromString(goban, "..o." +
+                    "oooo")
+  black, white := EstimatePoints(goban)
+  c.Check(black, Equals, 0)
+  c.Check(white, Equals, 8)
+}
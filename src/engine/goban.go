@@ -44,6 +44,49 @@ type VisitorMarker interface {
   IsMarked(y, x int) bool
 }
 
+// Gobans that track groups incrementally can answer these queries
+// without flood filling the board.
+type GroupTracker interface {
+  HasLiberties(y, x int) bool
+  InAtari(y, x int) bool
+  Suicide(y, x int, color Color) bool
+  RemoveGroup(y, x int) int
+}
+
+// Gobans that can count liberties faster than the generic flood fill.
+type LibertyCounter interface {
+  // Liberties of the group at y, x, stopping the count at limit.
+  LibertiesUpTo(y, x, limit int) int
+}
+
+// Available Goban implementations.
+const (
+  SLICE_GOBAN = iota
+  ARRAY_GOBAN
+  CHAIN_GOBAN
+  BITBOARD_GOBAN
+  PADDED_GOBAN
+)
+
+type GobanKind int
+
+// Implementation used by NewGameState and the GTP board size commands.
+var DefaultGoban GobanKind = SLICE_GOBAN
+
+func NewGoban(kind GobanKind, size_y, size_x int) Goban {
+  switch kind {
+  case ARRAY_GOBAN:
+    return NewArrayGoban(size_y, size_x)
+  case CHAIN_GOBAN:
+    return NewChainGoban(size_y, size_x)
+  case BITBOARD_GOBAN:
+    return NewBitboardGoban(size_y, size_x)
+  case PADDED_GOBAN:
+    return NewPaddedGoban(size_y, size_x)
+  }
+  return NewSliceGoban(size_y, size_x)
+}
+
 // --------------------------
 // These functions work with any Goban implementation.
 
@@ -51,6 +94,7 @@ var ascii_map = map[byte] Color {
   '.': EMPTY,
   'o': WHITE,
   'x': BLACK,
+  '#': INVALID,
  }
 
 func FromString(g Goban, s string) {
@@ -17,6 +17,7 @@
 package engine
 
 import . "launchpad.net/gocheck"
+import "strings"
 
 func (s *S) TestArrayGoban(c *C) {
   goban := NewArrayGoban(3, 4)
@@ -70,4 +71,211 @@ func checkGobanVisitorMarker(c *C, goban Goban) {
   c.Check(goban.GetColor(0, 0), Equals, Color(EMPTY))
 }
 
+func (s *S) TestLargeBoardLiberties(c *C) {
+  goban := NewSliceGoban(2, 300)
+  FromString(goban, strings.Repeat(".", 600))
+  for i := 0; i < 300; i++ {
+    goban.SetColor(0, i, BLACK)
+  }
+  c.Check(CountLiberties(goban, 0, 0), Equals, 300)
+  goban.SetColor(1, 299, WHITE)
+  c.Check(CountLiberties(goban, 0, 0), Equals, 299)
+}
+
+func (s *S) TestChainGoban(c *C) {
+  goban := NewChainGoban(3, 4)
+  checkGoban(c, goban)
+}
+
+func (s *S) TestChainGobanVisitorMarker(c *C) {
+  goban := NewChainGoban(1, 1)
+  checkGobanVisitorMarker(c, goban)
+}
+
+// Play random games on a tracking goban and a SliceGoban side by side,
+// and check that the incremental queries match the flood fill ones.
+func checkGroupTracker(c *C, create func (g Goban) Goban) {
+  slice := NewGameState(5, 5, 0.0, strings.Repeat(".", 25))
+  tracker := copyStateWithGoban(slice, create(slice.goban))
+  color := Color(BLACK)
+  for i := 0; i < 200; i++ {
+    move, ok := GetRandomMove(slice.goban, color)
+    if !ok {
+      break
+    }
+    Play(slice, move.y, move.x, color)
+    Play(tracker, move.y, move.x, color)
+    c.Assert(ToString(tracker.goban), Equals, ToString(slice.goban))
+    iterateAll(slice.goban, func (y, x int) {
+      if slice.goban.GetColor(y, x) == EMPTY {
+        for _, player := range []Color{BLACK, WHITE} {
+          c.Check(Suicide(tracker.goban, y, x, player), Equals,
+                  Suicide(slice.goban, y, x, player))
+        }
+      } else {
+        liberties := CountLiberties(slice.goban, y, x)
+        c.Check(hasLiberties(tracker.goban, y, x), Equals, liberties > 0)
+        c.Check(inAtari(tracker.goban, y, x), Equals, liberties == 1)
+      }
+    })
+    color = Opposite(color)
+  }
+}
+
+func (s *S) TestChainGobanQueries(c *C) {
+  checkGroupTracker(c, func (g Goban) Goban {
+    return NewChainGobanFrom(g)
+  })
+}
 
+func (s *S) TestChainGobanRemoveStone(c *C) {
+  goban := NewChainGoban(3, 3)
+  FromString(goban, "..." +
+                    "xxx" +
+                    "...")
+  c.Check(goban.InAtari(1, 0), Equals, false)
+  goban.SetColor(1, 1, EMPTY)
+  goban.SetColor(0, 0, WHITE)
+  goban.SetColor(2, 0, WHITE)
+  c.Check(goban.InAtari(1, 0), Equals, true)
+  c.Check(goban.InAtari(1, 2), Equals, false)
+  c.Check(goban.Suicide(1, 1, WHITE), Equals, false)
+  c.Check(goban.RemoveGroup(1, 0), Equals, 1)
+  c.Check(CountLiberties(goban, 0, 0), Equals, 2)
+}
+
+// Removing a stone rebuilds the rest of its group, which must match a
+// goban built from scratch.
+func (s *S) TestChainGobanRemoveFromGroup(c *C) {
+  goban := NewChainGoban(3, 5)
+  FromString(goban, "....." +
+                    "xxxxx" +
+                    "..o..")
+  goban.SetColor(1, 4, EMPTY)
+  fresh := NewChainGobanFrom(goban)
+  head := goban.points[5].head
+  for p := 5; p < 9; p++ {
+    c.Check(goban.points[p].head, Equals, head)
+  }
+  c.Check(goban.groups[head], Equals, fresh.groups[fresh.points[5].head])
+  c.Check(goban.groups[head].size, Equals, 4)
+  c.Check(CountLiberties(goban, 1, 3), Equals, 8)
+  c.Check(goban.HasLiberties(1, 0), Equals, true)
+  c.Check(goban.InAtari(1, 0), Equals, false)
+}
+
+func (s *S) TestBitboardGoban(c *C) {
+  goban := NewBitboardGoban(3, 4)
+  checkGoban(c, goban)
+  goban19 := NewBitboardGoban(19, 19)
+  FromString(goban19, strings.Repeat(".", 361))
+  goban19.SetColor(18, 18, BLACK)
+  goban19.SetColor(0, 0, WHITE)
+  c.Check(goban19.GetColor(18, 18), Equals, Color(BLACK))
+  c.Check(goban19.GetColor(0, 0), Equals, Color(WHITE))
+  c.Check(goban19.Liberties(18, 18), Equals, 2)
+}
+
+func (s *S) TestBitboardGobanVisitorMarker(c *C) {
+  goban := NewBitboardGoban(1, 1)
+  checkGobanVisitorMarker(c, goban)
+}
+
+func (s *S) TestBitboardGobanQueries(c *C) {
+  checkGroupTracker(c, func (g Goban) Goban {
+    return NewBitboardGobanFrom(g)
+  })
+}
+
+func (s *S) TestBitboardGobanLiberties(c *C) {
+  goban := NewBitboardGoban(3, 9)
+  FromString(goban, "o.o..ooo." +
+                    ".o.x.o.o." +
+                    "..xx.ooxx")
+  testcases := []struct {
+    y, x, liberties int
+  } {
+    {0, 0, 2}, {0, 2, 3}, {1, 1, 4}, {2, 3, 5}, {0, 5, 6}, {2, 8, 1},
+  }
+  for _, tc := range testcases {
+    c.Check(goban.Liberties(tc.y, tc.x), Equals, tc.liberties)
+    c.Check(CountLiberties(goban, tc.y, tc.x), Equals, tc.liberties)
+  }
+}
+
+func (s *S) TestPaddedGoban(c *C) {
+  goban := NewPaddedGoban(3, 4)
+  checkGoban(c, goban)
+}
+
+func (s *S) TestPaddedGobanVisitorMarker(c *C) {
+  goban := NewPaddedGoban(1, 1)
+  checkGobanVisitorMarker(c, goban)
+}
+
+func (s *S) TestPaddedGobanQueries(c *C) {
+  checkGroupTracker(c, func (g Goban) Goban {
+    return NewPaddedGobanFrom(g)
+  })
+}
+
+func (s *S) TestDefaultGoban(c *C) {
+  defer func (kind GobanKind) { DefaultGoban = kind }(DefaultGoban)
+  DefaultGoban = CHAIN_GOBAN
+  _, ok := NewGameState(3, 3, 0.0, ".........").goban.(*ChainGoban)
+  c.Check(ok, Equals, true)
+  state := new(GameState)
+  state.BoardSize(5)
+  _, ok = state.goban.(*ChainGoban)
+  c.Check(ok, Equals, true)
+}
+
+// Random games from the same position, on the default implementation.
+func benchmarkGoban(c *C, kind GobanKind) {
+  defer func (kind GobanKind) { DefaultGoban = kind }(DefaultGoban)
+  DefaultGoban = kind
+  root := NewGameState(9, 9, 6.5, strings.Repeat(".", 81))
+  Play(root, 4, 4, BLACK)
+  Play(root, 2, 6, WHITE)
+  c.ResetTimer()
+  for i := 0; i < c.N; i++ {
+    state := copyState(root)
+    state.goban.SetStack(root.goban.GetStack())
+    PlayRandomGame(state, BLACK)
+  }
+}
+
+func (s *S) BenchmarkSliceGoban(c *C) {
+  benchmarkGoban(c, SLICE_GOBAN)
+}
+
+func (s *S) BenchmarkArrayGoban(c *C) {
+  benchmarkGoban(c, ARRAY_GOBAN)
+}
+
+func (s *S) BenchmarkChainGoban(c *C) {
+  benchmarkGoban(c, CHAIN_GOBAN)
+}
+
+func (s *S) BenchmarkBitboardGoban(c *C) {
+  benchmarkGoban(c, BITBOARD_GOBAN)
+}
+
+func (s *S) BenchmarkPaddedGoban(c *C) {
+  benchmarkGoban(c, PADDED_GOBAN)
+}
+
+func (s *S) TestNewGameStateWithGoban(c *C) {
+  kinds := []GobanKind{SLICE_GOBAN, ARRAY_GOBAN, CHAIN_GOBAN,
+                       BITBOARD_GOBAN, PADDED_GOBAN}
+  for _, kind := range kinds {
+    state := NewGameStateWithGoban(kind, 3, 4, 0.0, "xxox" +
+                                                    "xo.x" +
+                                                    ".xx.")
+    Play(state, 1, 2, BLACK)
+    c.Check(ToString(state.goban), Equals, "xx.x" +
+                                           "x.xx" +
+                                           ".xx.")
+    c.Check(state.captured_white, Equals, 2)
+  }
+}
@@ -18,24 +18,60 @@ package engine
 
 type GTP interface {
   BoardSize(size int)
+  RectangularBoardSize(size_y, size_x int)
   ClearBoard()
-  Play(y, x int, color Color)
+  Play(y, x int, color Color) bool
+  Pass(color Color)
   GenMove(color Color) (y, x int, pass bool)
   Komi(komi float32)
+  Rules(rules Ruleset)
 }
 
 func (s *GameState) BoardSize(size int) {
-  s.goban = NewSliceGoban(size, size)
+  s.RectangularBoardSize(size, size)
 }
 
+func (s *GameState) RectangularBoardSize(size_y, size_x int) {
+  s.goban = NewGoban(DefaultGoban, size_y, size_x)
+  s.ClearBoard()
+}
+
+// Remove every stone, keeping the holes of a shaped board.
 func (s *GameState) ClearBoard() {
   iterateAll(s.goban, func (y, x int) {
-    s.goban.SetColor(y, x, EMPTY)
+    if s.goban.GetColor(y, x) != INVALID {
+      s.goban.SetColor(y, x, EMPTY)
+    }
   })
+  s.captured_white, s.captured_black = 0, 0
+  s.moves = 0
+  s.handicap = 0
+  s.has_ko = false
+  s.has_last = false
+  s.history = nil
+  s.setup, s.record = nil, nil
+  s.region, s.region_playouts = nil, false
+  retrackEmpty(s)
+  s.recordPosition(EMPTY)
 }
 
-func (s *GameState) Play(y, x int, color Color) {
+// Play a move if it's legal under the current rules.
+func (s *GameState) Play(y, x int, color Color) bool {
+  if !IsLegal(s, y, x, color) {
+    return false
+  }
   Play(s, y, x, color)
+  s.recordPosition(color)
+  s.recordMove(y, x, false, color)
+  return true
+}
+
+func (s *GameState) Pass(color Color) {
+  s.moves++
+  s.has_ko = false
+  s.has_last = false
+  s.recordPosition(color)
+  s.recordMove(0, 0, true, color)
 }
 
 func (s *GameState) Komi(komi float32) {
@@ -45,4 +81,3 @@ func (s *GameState) Komi(komi float32) {
 func (s *GameState) GenMove(color Color) (y, x int, pass bool) {
   return GetBestMove(s, color, 2)
 }
-
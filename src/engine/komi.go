@@ -0,0 +1,169 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// Dynamic komi modes. The komi used by the playouts during search may
+// differ from the real komi, so that win rates don't saturate near 0 or 1
+// in handicap games. Win rates reported outside the search always use
+// the real komi.
+const (
+  NO_DYNAMIC_KOMI = iota
+  SITUATIONAL_KOMI
+  VALUE_KOMI
+)
+
+type KomiMode int
+
+// Each handicap stone is worth about this many points.
+const handicap_stone_value = 7.0
+
+// Value-based komi parameters: after every komi_window playouts, the komi
+// is moved by komi_step points if the win rate left the target band.
+const (
+  komi_window = 200
+  komi_step = 1.0
+  komi_low_rate = 0.45
+  komi_high_rate = 0.55
+)
+
+// Komi used by the search at the start of a GetBestMove call.
+// The situational komi starts at the value of the handicap stones and
+// decreases linearly until it vanishes at the middle of the game.
+func searchKomi(state *GameState) float32 {
+  if state.komi_mode != SITUATIONAL_KOMI || state.handicap < 2 {
+    return state.komi
+  }
+  area := state.goban.SizeX() * state.goban.SizeY()
+  phase := 1.0 - float32(state.moves) / float32(area / 2)
+  if phase <= 0 {
+    return state.komi
+  }
+  return state.komi + handicap_stone_value * float32(state.handicap) * phase
+}
+
+// Value-based komi, adjusted independently by each search worker.
+type komiAdjuster struct {
+  komi float32
+  limit float32
+  wins, total int
+}
+
+func newKomiAdjuster(state *GameState) *komiAdjuster {
+  adjuster := new(komiAdjuster)
+  adjuster.komi = searchKomi(state)
+  adjuster.limit = float32(state.goban.SizeX() * state.goban.SizeY())
+  return adjuster
+}
+
+// Record the result of a playout for color under the current komi.
+func (k *komiAdjuster) Update(win bool, color Color) {
+  k.total++
+  if win {
+    k.wins++
+  }
+  if k.total < komi_window {
+    return
+  }
+  rate := float32(k.wins) / float32(k.total)
+  k.wins, k.total = 0, 0
+  // A higher komi makes the game harder for black.
+  step := float32(komi_step)
+  if color == WHITE {
+    step = -step
+  }
+  switch {
+  case rate > komi_high_rate:
+    k.komi += step
+  case rate < komi_low_rate:
+    k.komi -= step
+  }
+  if k.komi > k.limit {
+    k.komi = k.limit
+  }
+  if k.komi < -k.limit {
+    k.komi = -k.limit
+  }
+}
+
+func (s *GameState) DynamicKomi(mode KomiMode) {
+  s.komi_mode = mode
+}
+
+func (s *GameState) GetKomiMode() KomiMode {
+  return s.komi_mode
+}
+
+func (s *GameState) Handicap(stones int) {
+  s.handicap = stones
+}
+
+// Maximum number of fixed handicap stones. Boards with an even side have
+// no middle lines, so only the corners can be used.
+func MaxFixedHandicap(size_y, size_x int) int {
+  switch {
+  case size_y < 7 || size_x < 7:
+    return 0
+  case size_y % 2 == 0 || size_x % 2 == 0:
+    return 4
+  }
+  return 9
+}
+
+// Handicap line and middle line along a side of the board. The handicap
+// points are on the third line up to 12x12, and on the fourth line above.
+func handicapLines(size int) (low, middle, high int) {
+  low = 2
+  if size > 12 {
+    low = 3
+  }
+  return low, size / 2, size - 1 - low
+}
+
+// Place the handicap stones at the standard points of the GTP
+// fixed_handicap command. The board must be empty.
+func (s *GameState) FixedHandicap(stones int) bool {
+  size_y, size_x := s.goban.SizeY(), s.goban.SizeX()
+  if stones < 2 || stones > MaxFixedHandicap(size_y, size_x) {
+    return false
+  }
+  empty := true
+  iterateAll(s.goban, func (y, x int) {
+    empty = empty && s.goban.GetColor(y, x) == EMPTY
+  })
+  if !empty {
+    return false
+  }
+  bottom, middle_y, top := handicapLines(size_y)
+  left, middle_x, right := handicapLines(size_x)
+  // Corners first, then the sides, and the center for odd handicaps.
+  points := []Position{
+    {bottom, left}, {top, right}, {top, left}, {bottom, right},
+    {middle_y, left}, {middle_y, right}, {bottom, middle_x}, {top, middle_x},
+  }
+  count := stones
+  if stones > 4 {
+    count = stones - stones % 2
+  }
+  for _, point := range points[:count] {
+    s.Setup(point.y, point.x, BLACK)
+  }
+  if stones > 4 && stones % 2 == 1 {
+    s.Setup(middle_y, middle_x, BLACK)
+  }
+  s.Handicap(stones)
+  return true
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestSituationalKomi(c *C) {
+  state := NewEmptyGameState(4, 4)
+  state.Handicap(2)
+  c.Check(searchKomi(state), Equals, float32(6.5))
+
+  state.DynamicKomi(SITUATIONAL_KOMI)
+  c.Check(searchKomi(state), Equals, float32(6.5 + 14.0))
+  state.moves = 4
+  c.Check(searchKomi(state), Equals, float32(6.5 + 7.0))
+  state.moves = 8
+  c.Check(searchKomi(state), Equals, float32(6.5))
+}
+
+func (s *S) TestValueKomi(c *C) {
+  state := NewEmptyGameState(4, 4)
+  state.DynamicKomi(VALUE_KOMI)
+  black := newKomiAdjuster(state)
+  for i := 0; i < komi_window; i++ {
+    black.Update(true, BLACK)
+  }
+  c.Check(black.komi, Equals, float32(6.5 + komi_step))
+
+  white := newKomiAdjuster(state)
+  for i := 0; i < komi_window; i++ {
+    white.Update(false, WHITE)
+  }
+  c.Check(white.komi, Equals, float32(6.5 + komi_step))
+
+  balanced := newKomiAdjuster(state)
+  for i := 0; i < komi_window; i++ {
+    balanced.Update(i % 2 == 0, BLACK)
+  }
+  c.Check(balanced.komi, Equals, float32(6.5))
+}
+
+func (s *S) TestFixedHandicap(c *C) {
+  state := NewEmptyGameState(9, 9)
+  c.Check(state.FixedHandicap(1), Equals, false)
+  c.Check(state.FixedHandicap(10), Equals, false)
+  c.Check(state.FixedHandicap(5), Equals, true)
+  c.Check(state.GetHandicap(), Equals, 5)
+  expected := NewArrayGoban(9, 9)
+  FromString(expected, "........." +
+                       "........." +
+                       "..x...x.." +
+                       "........." +
+                       "....x...." +
+                       "........." +
+                       "..x...x.." +
+                       "........." +
+                       ".........")
+  c.Check(RenderGoban(state.goban), Equals, RenderGoban(expected))
+  c.Check(state.FixedHandicap(2), Equals, false)
+
+  even := NewEmptyGameState(8, 8)
+  c.Check(MaxFixedHandicap(8, 8), Equals, 4)
+  c.Check(even.FixedHandicap(5), Equals, false)
+  c.Check(even.FixedHandicap(4), Equals, true)
+}
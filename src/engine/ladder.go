@@ -0,0 +1,192 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Ladder reader.
+//
+// The defender escapes by extending at its last liberty or by capturing
+// an attacker group in atari, and the attacker chases by playing atari
+// on either of the two liberties. Every ply of the reading is played on
+// its own scratch goban, allocated once, so reading doesn't allocate.
+
+type ladderLevel struct {
+  state GameState
+  goban *ChainGoban
+  moves, stones []Position
+}
+
+type LadderReader struct {
+  size_y, size_x int
+  levels []*ladderLevel
+  nodes int
+}
+
+// Readings that visit more positions than this are considered escaped.
+const ladder_nodes = 500
+
+// The reader preallocates a scratch goban for each ply of the longest
+// ladder on a board of this size.
+func NewLadderReader(size_y, size_x int) *LadderReader {
+  r := new(LadderReader)
+  r.resize(size_y, size_x)
+  return r
+}
+
+func (r *LadderReader) resize(size_y, size_x int) {
+  r.size_y, r.size_x = size_y, size_x
+  r.levels = make([]*ladderLevel, r.maxDepth() + 1)
+  for i := range r.levels {
+    level := new(ladderLevel)
+    level.goban = NewChainGoban(size_y, size_x)
+    level.moves = make([]Position, 0, size_y * size_x)
+    level.stones = make([]Position, 0, size_y * size_x)
+    r.levels[i] = level
+  }
+}
+
+// Ladders can't be longer than a path across the board.
+func (r *LadderReader) maxDepth() int {
+  return 2 * (r.size_y + r.size_x)
+}
+
+// Scratch level at the given depth, with a copy of the level above it.
+func (r *LadderReader) level(depth int) *ladderLevel {
+  r.nodes++
+  level := r.levels[depth]
+  above := r.levels[depth - 1]
+  level.goban.CopyFrom(above.goban)
+  loadState(&level.state, &above.state, level.goban)
+  return level
+}
+
+// Copy the goban to the first level.
+func (r *LadderReader) load(g Goban) *ladderLevel {
+  if g.SizeY() != r.size_y || g.SizeX() != r.size_x {
+    r.resize(g.SizeY(), g.SizeX())
+  }
+  r.nodes = 0
+  level := r.levels[0]
+  if chain, ok := g.(*ChainGoban); ok {
+    level.goban.CopyFrom(chain)
+  } else {
+    iterateAll(g, func (y, x int) {
+      level.goban.SetColor(y, x, g.GetColor(y, x))
+    })
+  }
+  level.state = GameState{goban: level.goban}
+  return level
+}
+
+// Check if the group at y, x is captured in a ladder. A group in atari
+// is read with the defender to play, and a group with two liberties with
+// the attacker to play. Other groups are never captured.
+func (r *LadderReader) Captured(g Goban, y, x int) bool {
+  level := r.load(g)
+  switch CountLiberties(level.goban, y, x) {
+  case 1:
+    return !r.escapes(0, y, x)
+  case 2:
+    return r.attack(0, y, x)
+  }
+  return false
+}
+
+// Check if the group at y, x is captured in a ladder after color plays
+// at move, with the other color to play next.
+func (r *LadderReader) CapturedAfter(g Goban, move Position, color Color,
+                                     y, x int) bool {
+  level := r.load(g)
+  if Suicide(level.goban, move.y, move.x, color) {
+    return false
+  }
+  Play(&level.state, move.y, move.x, color)
+  defender := level.goban.GetColor(y, x)
+  if defender == EMPTY {
+    return true
+  }
+  switch CountLiberties(level.goban, y, x) {
+  case 1:
+    return defender == color || !r.escapes(0, y, x)
+  case 2:
+    return defender == color && r.attack(0, y, x)
+  }
+  return false
+}
+
+// The group at y, x is in atari, with the defender to play.
+func (r *LadderReader) escapes(depth, y, x int) bool {
+  if depth >= r.maxDepth() || r.nodes >= ladder_nodes {
+    return true
+  }
+  level := r.levels[depth]
+  g := level.goban
+  color := g.GetColor(y, x)
+  level.moves = append(level.moves[:0], lastLiberty(g, y, x))
+  level.stones = level.stones[:0]
+  iterateGroup(g, y, x, func (ny, nx int) {}, func (ny, nx int) {
+    if g.GetColor(ny, nx) == Opposite(color) {
+      level.stones = append(level.stones, Position{ny, nx})
+    }
+  })
+  for _, stone := range level.stones {
+    if inAtari(g, stone.y, stone.x) {
+      level.moves = append(level.moves, lastLiberty(g, stone.y, stone.x))
+    }
+  }
+  for _, move := range level.moves {
+    next := r.level(depth + 1)
+    if Suicide(next.goban, move.y, move.x, color) {
+      continue
+    }
+    Play(&next.state, move.y, move.x, color)
+    switch liberties := CountLiberties(next.goban, y, x); {
+    case liberties >= 3:
+      return true
+    case liberties == 2 && !r.attack(depth + 1, y, x):
+      return true
+    }
+  }
+  return false
+}
+
+// The group at y, x has two liberties, with the attacker to play.
+func (r *LadderReader) attack(depth, y, x int) bool {
+  if depth >= r.maxDepth() || r.nodes >= ladder_nodes {
+    return false
+  }
+  level := r.levels[depth]
+  g := level.goban
+  attacker := Opposite(g.GetColor(y, x))
+  level.moves = level.moves[:0]
+  iterateGroup(g, y, x, func (ny, nx int) {}, func (ny, nx int) {
+    if g.GetColor(ny, nx) == EMPTY {
+      level.moves = append(level.moves, Position{ny, nx})
+    }
+  })
+  for _, move := range level.moves {
+    next := r.level(depth + 1)
+    if Suicide(next.goban, move.y, move.x, attacker) {
+      continue
+    }
+    Play(&next.state, move.y, move.x, attacker)
+    if inAtari(next.goban, y, x) && !r.escapes(depth + 1, y, x) {
+      return true
+    }
+  }
+  return false
+}
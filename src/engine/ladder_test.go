@@ -0,0 +1,71 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+// A white stone with two liberties, that can be laddered towards both
+// corners.
+func newLadderGoban() Goban {
+  goban := NewSliceGoban(9, 9)
+  FromString(goban, "........." +
+                    "........." +
+                    "........." +
+                    "....x...." +
+                    "...xo...." +
+                    ".....x..." +
+                    "........." +
+                    "........." +
+                    ".........")
+  return goban
+}
+
+func (s *S) TestLadderCaptured(c *C) {
+  goban := newLadderGoban()
+  reader := NewLadderReader(9, 9)
+  c.Check(reader.Captured(goban, 4, 4), Equals, true)
+  // A breaker on one side is not enough.
+  goban.SetColor(6, 2, WHITE)
+  c.Check(reader.Captured(goban, 4, 4), Equals, true)
+  goban.SetColor(2, 6, WHITE)
+  c.Check(reader.Captured(goban, 4, 4), Equals, false)
+}
+
+func (s *S) TestLadderInAtari(c *C) {
+  goban := newLadderGoban()
+  goban.SetColor(5, 4, BLACK)
+  reader := NewLadderReader(9, 9)
+  c.Check(reader.Captured(goban, 4, 4), Equals, true)
+  c.Check(reader.CapturedAfter(goban, Position{4, 5}, WHITE, 4, 4),
+          Equals, true)
+  goban.SetColor(2, 6, WHITE)
+  c.Check(reader.Captured(goban, 4, 4), Equals, false)
+  c.Check(reader.CapturedAfter(goban, Position{4, 5}, WHITE, 4, 4),
+          Equals, false)
+}
+
+func (s *S) TestLadderPriors(c *C) {
+  state := NewGameState(9, 9, 0.0, ToString(newLadderGoban()))
+  moves := []Position{{5, 4}, {4, 5}, {0, 0}}
+  priors := movePriors(state, moves, BLACK)
+  c.Check(priors[0].rate > 0.5, Equals, true)
+  c.Check(priors[1].rate > 0.5, Equals, true)
+  c.Check(priors[2].rate, Equals, 0.5)
+  Play(state, 5, 4, BLACK)
+  priors = movePriors(state, []Position{{4, 5}}, WHITE)
+  c.Check(priors[0].rate < 0.5, Equals, true)
+}
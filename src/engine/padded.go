@@ -0,0 +1,210 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Goban implementation using a single slice with a border of INVALID
+// points around the board. The neighbours of any point on the board are
+// at fixed offsets, and never out of the slice, so no bounds checks are
+// needed while walking groups.
+
+type PaddedGoban struct {
+  size_x, size_y int
+  stride int
+  offsets [4]int
+  board []Color
+  marks []uint32
+  generation uint32
+  queue []int
+  stack Stack
+}
+
+func NewPaddedGoban(size_y, size_x int) *PaddedGoban {
+  goban := new(PaddedGoban)
+  goban.size_x = size_x
+  goban.size_y = size_y
+  goban.stride = size_x + 2
+  goban.offsets = [4]int{1, -1, goban.stride, -goban.stride}
+  goban.board = make([]Color, (size_y + 2) * goban.stride)
+  for i := range goban.board {
+    goban.board[i] = INVALID
+  }
+  for j := 0; j < size_y; j++ {
+    for i := 0; i < size_x; i++ {
+      goban.board[goban.index(j, i)] = EMPTY
+    }
+  }
+  goban.marks = make([]uint32, len(goban.board))
+  goban.generation = 1
+  goban.queue = make([]int, 0, size_x * size_y)
+  goban.stack = NewSliceStack(size_x * size_y)
+  return goban
+}
+
+// Build a PaddedGoban with the same stones as any other Goban.
+func NewPaddedGobanFrom(g Goban) *PaddedGoban {
+  goban := NewPaddedGoban(g.SizeY(), g.SizeX())
+  iterateAll(g, func (y, x int) {
+    goban.SetColor(y, x, g.GetColor(y, x))
+  })
+  return goban
+}
+
+func (g *PaddedGoban) index(y, x int) int {
+  return (y + 1) * g.stride + x + 1
+}
+
+func (g *PaddedGoban) Copy() Goban {
+  new_goban := new(PaddedGoban)
+  *new_goban = *g
+  new_goban.board = make([]Color, len(g.board))
+  copy(new_goban.board, g.board)
+  new_goban.marks = make([]uint32, len(g.marks))
+  new_goban.generation = 1
+  new_goban.queue = make([]int, 0, cap(g.queue))
+  new_goban.stack = nil
+  return new_goban
+}
+
+func (g *PaddedGoban) SizeX() int {
+  return g.size_x
+}
+
+func (g *PaddedGoban) SizeY() int {
+  return g.size_y
+}
+
+func (g *PaddedGoban) GetColor(y, x int) Color {
+  return g.board[g.index(y, x)]
+}
+
+func (g *PaddedGoban) SetColor(y, x int, color Color) {
+  g.board[g.index(y, x)] = color
+}
+
+func (g *PaddedGoban) GetVisitorMarker() VisitorMarker {
+  return g
+}
+
+func (g *PaddedGoban) ClearMarks() {
+  g.generation++
+  if g.generation == 0 {
+    for i := range g.marks {
+      g.marks[i] = 0
+    }
+    g.generation = 1
+  }
+}
+
+func (g *PaddedGoban) SetMark(y, x int) {
+  g.marks[g.index(y, x)] = g.generation
+}
+
+func (g *PaddedGoban) IsMarked(y, x int) bool {
+  return g.marks[g.index(y, x)] == g.generation
+}
+
+func (g *PaddedGoban) GetStack() Stack {
+  return g.stack
+}
+
+func (g *PaddedGoban) SetStack(stack Stack) {
+  g.stack = stack
+}
+
+// --------------------------
+// GroupTracker implementation, walking groups without closures.
+
+// Count the liberties of the group at p, stopping at limit.
+func (g *PaddedGoban) countLiberties(p, limit int) int {
+  g.ClearMarks()
+  color := g.board[p]
+  liberties := 0
+  g.queue = append(g.queue[:0], p)
+  g.marks[p] = g.generation
+  for i := 0; i < len(g.queue); i++ {
+    for _, offset := range g.offsets {
+      q := g.queue[i] + offset
+      if g.marks[q] == g.generation {
+        continue
+      }
+      switch g.board[q] {
+      case EMPTY:
+        g.marks[q] = g.generation
+        liberties++
+        if liberties >= limit {
+          return liberties
+        }
+      case color:
+        g.marks[q] = g.generation
+        g.queue = append(g.queue, q)
+      }
+    }
+  }
+  return liberties
+}
+
+func (g *PaddedGoban) LibertiesUpTo(y, x, limit int) int {
+  return g.countLiberties(g.index(y, x), limit)
+}
+
+func (g *PaddedGoban) HasLiberties(y, x int) bool {
+  return g.countLiberties(g.index(y, x), 1) > 0
+}
+
+func (g *PaddedGoban) InAtari(y, x int) bool {
+  return g.countLiberties(g.index(y, x), 2) == 1
+}
+
+func (g *PaddedGoban) Suicide(y, x int, color Color) bool {
+  p := g.index(y, x)
+  for _, offset := range g.offsets {
+    q := p + offset
+    switch g.board[q] {
+    case EMPTY:
+      return false
+    case color:
+      // A friendly group with another liberty besides p.
+      if g.countLiberties(q, 2) > 1 {
+        return false
+      }
+    case Opposite(color):
+      // An opponent group that is captured.
+      if g.countLiberties(q, 2) == 1 {
+        return false
+      }
+    }
+  }
+  return true
+}
+
+func (g *PaddedGoban) RemoveGroup(y, x int) int {
+  p := g.index(y, x)
+  color := g.board[p]
+  g.queue = append(g.queue[:0], p)
+  g.board[p] = EMPTY
+  for i := 0; i < len(g.queue); i++ {
+    for _, offset := range g.offsets {
+      q := g.queue[i] + offset
+      if g.board[q] == color {
+        g.board[q] = EMPTY
+        g.queue = append(g.queue, q)
+      }
+    }
+  }
+  return len(g.queue)
+}
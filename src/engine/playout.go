@@ -0,0 +1,73 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Per-worker playout buffers.
+//
+// Each search worker owns a playout, with a GameState, a goban and a list
+// of empty points allocated once. Every playout restores the goban from
+// the root position in place, so the playouts themselves don't allocate.
+
+type playout struct {
+  root *GameState
+  goban *ChainGoban
+  start *ChainGoban
+  state GameState
+  policy Policy
+  // Empty points of the starting position.
+  empty []int
+  empty_index []int
+  // Capture difference of the root, for the mercy rule.
+  captures int
+}
+
+// The policy tries the replies in the table first, unless it's nil.
+func newPlayout(root *GameState, replies *ReplyTable) *playout {
+  p := new(playout)
+  p.root = root
+  p.captures = root.captured_white - root.captured_black
+  // Playouts use a goban with incremental group tracking.
+  p.start = NewChainGobanFrom(root.goban)
+  p.goban = NewChainGoban(root.goban.SizeY(), root.goban.SizeX())
+  loadState(&p.state, root, p.start)
+  p.policy = newPolicy(root.policy, root)
+  if replies != nil {
+    p.policy = replyPolicy{replies, p.policy}
+  }
+  area := root.goban.SizeY() * root.goban.SizeX()
+  p.state.sequence = make([]playoutMove, 0, 3 * area + 1)
+  trackEmpty(&p.state)
+  p.empty = append([]int(nil), p.state.empty...)
+  p.empty_index = append([]int(nil), p.state.empty_index...)
+  return p
+}
+
+// Play move with color from the root position, then a game with the
+// playout policy until the end. The returned state is reused by the next
+// call.
+func (p *playout) Run(move Position, color Color) *GameState {
+  p.goban.CopyFrom(p.start)
+  loadState(&p.state, p.root, p.goban)
+  p.state.empty = append(p.state.empty[:0], p.empty...)
+  copy(p.state.empty_index, p.empty_index)
+  Play(&p.state, move.y, move.x, color)
+  point := encode(p.goban, move.y, move.x)
+  p.state.sequence = append(p.state.sequence[:0], playoutMove{color, point})
+  playRandomGame(&p.state, Opposite(color), p.policy, p.captures)
+  return &p.state
+}
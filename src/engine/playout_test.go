@@ -0,0 +1,169 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+import "strings"
+import "testing"
+
+func newPlayoutState() *GameState {
+  state := NewGameState(9, 9, 6.5, strings.Repeat(".", 81))
+  Play(state, 4, 4, BLACK)
+  Play(state, 2, 6, WHITE)
+  return state
+}
+
+func (s *S) TestPlayoutKeepsRoot(c *C) {
+  state := newPlayoutState()
+  before := ToString(state.goban)
+  playout := newPlayout(state, nil)
+  for i := 0; i < 10; i++ {
+    result := playout.Run(Position{6, 2}, BLACK)
+    c.Check(result.goban.GetColor(6, 2), Not(Equals), EMPTY)
+  }
+  c.Check(ToString(state.goban), Equals, before)
+  c.Check(state.moves, Equals, 2)
+}
+
+// A policy that always passes, so the playout ends after the candidate.
+type passPolicy struct{}
+
+func (passPolicy) Move(state *GameState, color Color) (Position, bool) {
+  return Position{}, false
+}
+
+// The candidate move is played with the rules, so it can capture.
+func (s *S) TestPlayoutCapture(c *C) {
+  state := newPlayoutState()
+  Play(state, 0, 1, BLACK)
+  Play(state, 0, 0, WHITE)
+  playout := newPlayout(state, nil)
+  playout.policy = passPolicy{}
+  result := playout.Run(Position{1, 0}, BLACK)
+  c.Check(result.goban.GetColor(0, 0), Equals, Color(EMPTY))
+  c.Check(result.captured_white, Equals, 1)
+}
+
+func (s *S) TestPlayoutAllocs(c *C) {
+  playout := newPlayout(newPlayoutState(), nil)
+  // The first run allocates the scoring buffer.
+  Winner(playout.Run(Position{6, 2}, BLACK))
+  allocs := testing.AllocsPerRun(50, func() {
+    Winner(playout.Run(Position{6, 2}, BLACK))
+  })
+  c.Check(allocs, Equals, 0.0)
+}
+
+func (s *S) BenchmarkPlayout(c *C) {
+  playout := newPlayout(newPlayoutState(), nil)
+  for i := 0; i < c.N; i++ {
+    Winner(playout.Run(Position{6, 2}, BLACK))
+  }
+}
+
+func (s *S) TestTrackEmpty(c *C) {
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 5, 5, 0.0,
+                                 strings.Repeat(".", 25))
+  trackEmpty(state)
+  color := Color(BLACK)
+  for i := 0; i < 100; i++ {
+    move, ok := randomMove(state, color)
+    if !ok {
+      break
+    }
+    Play(state, move.y, move.x, color)
+    color = Opposite(color)
+    checkEmpty(c, state)
+  }
+}
+
+// Check that the list of empty points matches the goban.
+func checkEmpty(c *C, state *GameState) {
+  empty := 0
+  iterateAll(state.goban, func (y, x int) {
+    index := state.empty_index[encode(state.goban, y, x)]
+    if state.goban.GetColor(y, x) == EMPTY &&
+       state.inPlayoutRegion(encode(state.goban, y, x)) {
+      empty++
+      c.Assert(index >= 0, Equals, true)
+      c.Assert(state.empty[index], Equals, encode(state.goban, y, x))
+    } else {
+      c.Assert(index, Equals, -1)
+    }
+  })
+  c.Assert(len(state.empty), Equals, empty)
+}
+
+func (s *S) TestTrackEmptyOutsidePlay(c *C) {
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 3, 3, 0.0, "x........")
+  trackEmpty(state)
+  state.Setup(1, 1, WHITE)
+  checkEmpty(c, state)
+  state.Setup(0, 0, EMPTY)
+  checkEmpty(c, state)
+  region := make([]bool, 9)
+  region[0], region[1] = true, true
+  state.SetRegion(region, true)
+  checkEmpty(c, state)
+  c.Check(state.empty, HasLen, 2)
+  state.ClearBoard()
+  checkEmpty(c, state)
+  c.Check(state.empty, HasLen, 9)
+}
+
+func (s *S) TestMercyRule(c *C) {
+  state := newPlayoutState()
+  c.Check(mercyWinner(state, 0), Equals, Color(EMPTY))
+  state.captured_white = 21
+  c.Check(mercyWinner(state, 0), Equals, Color(BLACK))
+  state.captured_black = 10
+  c.Check(mercyWinner(state, 0), Equals, Color(EMPTY))
+  state.captured_black = 42
+  c.Check(mercyWinner(state, 0), Equals, Color(WHITE))
+  // Only the captures since the start of the playout count.
+  c.Check(mercyWinner(state, -21), Equals, Color(EMPTY))
+  // The winner of a stopped playout ignores the score.
+  state.early_winner = WHITE
+  c.Check(Winner(state), Equals, Color(WHITE))
+}
+
+// A large capture difference at the root doesn't stop the playouts.
+func (s *S) TestMercyRuleFromRoot(c *C) {
+  state := newPlayoutState()
+  state.captured_white = 30
+  playout := newPlayout(state, nil)
+  for i := 0; i < 10; i++ {
+    result := playout.Run(Position{6, 2}, BLACK)
+    c.Check(len(result.sequence) > 2, Equals, true)
+  }
+}
+
+func (s *S) TestOnlyEyes(c *C) {
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 2, 8, 0.0, ".x.xo.o." +
+                                                         "xxxxoooo")
+  trackEmpty(state)
+  c.Check(onlyEyes(state), Equals, true)
+  // The black group in atari can still be captured.
+  state = NewGameStateWithGoban(CHAIN_GOBAN, 2, 8, 0.0, "xx.xo.o." +
+                                                        "xxxxoooo")
+  trackEmpty(state)
+  c.Check(onlyEyes(state), Equals, false)
+  state = NewGameStateWithGoban(CHAIN_GOBAN, 2, 8, 0.0, ".x..o.o." +
+                                                        "xxxxoooo")
+  trackEmpty(state)
+  c.Check(onlyEyes(state), Equals, false)
+}
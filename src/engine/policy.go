@@ -0,0 +1,338 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "math/rand"
+
+// Playout policies.
+const (
+  RANDOM_POLICY = iota
+  HEAVY_POLICY
+)
+
+type PolicyKind int
+
+// A playout policy chooses the moves of the random games. Each search
+// worker has its own Policy, so implementations can keep buffers.
+type Policy interface {
+  // Pick a move for color, or return false to pass.
+  Move(state *GameState, color Color) (Position, bool)
+}
+
+func newPolicy(kind PolicyKind, state *GameState) Policy {
+  switch kind {
+  case HEAVY_POLICY:
+    return newHeavyPolicy(state)
+  }
+  return RandomPolicy{}
+}
+
+func (s *GameState) PlayoutPolicy(kind PolicyKind) {
+  s.policy = kind
+}
+
+func (s *GameState) GetPlayoutPolicy() PolicyKind {
+  return s.policy
+}
+
+// --------------------------
+// Uniformly random moves, except filling our own eyes.
+
+type RandomPolicy struct{}
+
+func (RandomPolicy) Move(state *GameState, color Color) (Position, bool) {
+  return randomMove(state, color)
+}
+
+// --------------------------
+// MoGo style heavy policy. Looking around the last move, in order:
+//   - capture a group in atari;
+//   - save one of our groups in atari, by capturing or by extending out
+//     of a ladder;
+//   - play the vital point of a small eye space;
+//   - answer with a move matching a 3x3 pattern;
+// and fall back to a random move.
+
+type HeavyPolicy struct {
+  captures, escapes, vitals, patterns []Position
+  groups, space []Position
+  ladder *LadderReader
+}
+
+func newHeavyPolicy(state *GameState) *HeavyPolicy {
+  size := state.goban.SizeY() * state.goban.SizeX()
+  policy := new(HeavyPolicy)
+  policy.captures = make([]Position, 0, size)
+  policy.escapes = make([]Position, 0, size)
+  policy.vitals = make([]Position, 0, 4)
+  policy.patterns = make([]Position, 0, 8)
+  policy.space = make([]Position, 0, max_eye_space)
+  policy.groups = make([]Position, 0, size)
+  policy.ladder = NewLadderReader(state.goban.SizeY(),
+                                  state.goban.SizeX())
+  return policy
+}
+
+func (h *HeavyPolicy) Move(state *GameState, color Color) (Position, bool) {
+  if state.has_last {
+    g := state.goban
+    h.captures = h.captures[:0]
+    h.escapes = h.escapes[:0]
+    h.atariMoves(state, state.last.y, state.last.x, color)
+    iterateNeighbours(g, state.last.y, state.last.x, func (y, x int) {
+      h.atariMoves(state, y, x, color)
+    })
+    if move, ok := pickValid(state, h.captures, color); ok {
+      return move, true
+    }
+    if move, ok := pickValid(state, h.escapes, color); ok {
+      return move, true
+    }
+    h.vitals = h.vitals[:0]
+    iterateNeighbours(g, state.last.y, state.last.x, func (y, x int) {
+      if g.GetColor(y, x) != EMPTY {
+        return
+      }
+      var ok bool
+      _, h.space, ok = appendEyeSpace(g, y, x, h.space)
+      if ok {
+        if vital, ok := VitalPoint(h.space); ok {
+          h.vitals = append(h.vitals, vital)
+        }
+      }
+    })
+    if move, ok := pickValid(state, h.vitals, color); ok {
+      return move, true
+    }
+    h.patterns = h.patterns[:0]
+    for i := 0; i < 8; i++ {
+      y, x := state.last.y + pattern_dy[i], state.last.x + pattern_dx[i]
+      if valid(y, x, g.SizeY(), g.SizeX()) && g.GetColor(y, x) == EMPTY &&
+         MatchPattern(g, y, x) {
+        h.patterns = append(h.patterns, Position{y, x})
+      }
+    }
+    if move, ok := pickValid(state, h.patterns, color); ok {
+      return move, true
+    }
+  }
+  return randomMove(state, color)
+}
+
+// Collect the captures and escapes for the group at y, x, if it's in atari.
+func (h *HeavyPolicy) atariMoves(state *GameState, y, x int, color Color) {
+  g := state.goban
+  stone := g.GetColor(y, x)
+  if stone != BLACK && stone != WHITE || !inAtari(g, y, x) {
+    return
+  }
+  liberty := lastLiberty(g, y, x)
+  if stone != color {
+    h.captures = append(h.captures, liberty)
+    return
+  }
+  // Extending only helps if the group isn't caught in a ladder.
+  if !h.ladder.CapturedAfter(g, liberty, color, y, x) {
+    h.escapes = append(h.escapes, liberty)
+  }
+  // Capturing a neighbour group in atari also saves the group.
+  h.groups = h.groups[:0]
+  iterateGroup(g, y, x, func (ny, nx int) {}, func (ny, nx int) {
+    if g.GetColor(ny, nx) == Opposite(color) {
+      h.groups = append(h.groups, Position{ny, nx})
+    }
+  })
+  for _, p := range h.groups {
+    if inAtari(g, p.y, p.x) {
+      h.escapes = append(h.escapes, lastLiberty(g, p.y, p.x))
+    }
+  }
+}
+
+// Remove invalid moves from the candidates and pick one at random.
+func pickValid(state *GameState, moves []Position, color Color) (
+    Position, bool) {
+  g := state.goban
+  for n := len(moves); n > 0; n-- {
+    i := rand.Intn(n)
+    if state.inPlayoutRegion(encode(g, moves[i].y, moves[i].x)) &&
+       validMove(g, moves[i].y, moves[i].x, color) {
+      return moves[i], true
+    }
+    moves[i], moves[n - 1] = moves[n - 1], moves[i]
+  }
+  return Position{0, 0}, false
+}
+
+// The only liberty of a group in atari.
+func lastLiberty(g Goban, y, x int) Position {
+  if chain, ok := g.(*ChainGoban); ok {
+    lib, _ := chain.inAtari(chain.points[y * chain.size_x + x].head)
+    return Position{lib / chain.size_x, lib % chain.size_x}
+  }
+  var liberty Position
+  iterateGroup(g, y, x, func (ny, nx int) {}, func (ny, nx int) {
+    if g.GetColor(ny, nx) == EMPTY {
+      liberty = Position{ny, nx}
+    }
+  })
+  return liberty
+}
+
+// --------------------------
+// 3x3 patterns.
+//
+// The patterns are centered on the move, with X and O standing for
+// either color, as long as they are different. Besides those, . is
+// empty, # is off the board, x is anything but X, o is anything but O,
+// and ? is anything. Every pattern also matches in all its rotations and
+// reflections.
+
+var mogo_patterns = []string{
+  // Hane.
+  "XOX" +
+  "..." +
+  "???",
+  "XO." +
+  "..." +
+  "?.?",
+  "XO?" +
+  "X.." +
+  "x.?",
+  "XOO" +
+  "..." +
+  "?.?",
+  // Cut.
+  "XO?" +
+  "O.o" +
+  "?o?",
+  "XO?" +
+  "O.X" +
+  "???",
+  "?X?" +
+  "O.O" +
+  "ooo",
+  // Edge.
+  "X.?" +
+  "O.?" +
+  "###",
+  "OX?" +
+  "X.O" +
+  "###",
+  "?X?" +
+  "x.O" +
+  "###",
+  "?XO" +
+  "x.x" +
+  "###",
+  "?OX" +
+  "X.O" +
+  "###",
+}
+
+// Neighbours of the center, in the order of the pattern strings.
+var pattern_dy = []int{1, 1, 1, 0, 0, -1, -1, -1}
+var pattern_dx = []int{-1, 0, 1, -1, 1, -1, 0, 1}
+var pattern_cells = []int{0, 1, 2, 3, 5, 6, 7, 8}
+
+const pattern_edge = 3
+
+// Matching neighbourhoods, indexed by two bits per neighbour.
+var pattern_table [1 << 16]bool
+
+func init() {
+  for _, pattern := range mogo_patterns {
+    for _, symmetric := range patternSymmetries(pattern) {
+      expandPattern(symmetric, 0, 0, false)
+      expandPattern(symmetric, 0, 0, true)
+    }
+  }
+}
+
+// The 8 rotations and reflections of a 3x3 pattern.
+func patternSymmetries(pattern string) []string {
+  result := make([]string, 0, 8)
+  for s := 0; s < 8; s++ {
+    cells := make([]byte, 9)
+    for r := 0; r < 3; r++ {
+      for c := 0; c < 3; c++ {
+        nr, nc := r, c
+        if s & 1 != 0 {
+          nc = 2 - nc
+        }
+        if s & 2 != 0 {
+          nr = 2 - nr
+        }
+        if s & 4 != 0 {
+          nr, nc = nc, nr
+        }
+        cells[nr * 3 + nc] = pattern[r * 3 + c]
+      }
+    }
+    result = append(result, string(cells))
+  }
+  return result
+}
+
+// Add all neighbourhoods matching the pattern from the given neighbour
+// on. With swap, X is white instead of black.
+func expandPattern(pattern string, i, key int, swap bool) {
+  if i == len(pattern_cells) {
+    pattern_table[key] = true
+    return
+  }
+  x, o := BLACK, WHITE
+  if swap {
+    x, o = o, x
+  }
+  var values []int
+  switch pattern[pattern_cells[i]] {
+  case 'X':
+    values = []int{x}
+  case 'O':
+    values = []int{o}
+  case '.':
+    values = []int{EMPTY}
+  case '#':
+    values = []int{pattern_edge}
+  case 'x':
+    values = []int{EMPTY, o, pattern_edge}
+  case 'o':
+    values = []int{EMPTY, x, pattern_edge}
+  case '?':
+    values = []int{EMPTY, BLACK, WHITE, pattern_edge}
+  }
+  for _, value := range values {
+    expandPattern(pattern, i + 1, key | value << uint(2 * i), swap)
+  }
+}
+
+// Check if the neighbourhood of y, x matches one of the patterns.
+func MatchPattern(g Goban, y, x int) bool {
+  key := 0
+  for i := 0; i < 8; i++ {
+    ny, nx := y + pattern_dy[i], x + pattern_dx[i]
+    value := pattern_edge
+    if valid(ny, nx, g.SizeY(), g.SizeX()) {
+      if color := g.GetColor(ny, nx); color != INVALID {
+        value = int(color)
+      }
+    }
+    key |= value << uint(2 * i)
+  }
+  return pattern_table[key]
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+import "strings"
+import "testing"
+
+func (s *S) TestHeavyPolicyAtari(c *C) {
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 5, 5, 0.0, "....." +
+                                                         "..x.." +
+                                                         ".xo.." +
+                                                         "..x.." +
+                                                         ".....")
+  state.last = Position{2, 1}
+  state.has_last = true
+  trackEmpty(state)
+  policy := newHeavyPolicy(state)
+  // Black captures, white extends.
+  for _, color := range []Color{BLACK, WHITE} {
+    move, ok := policy.Move(state, color)
+    c.Check(ok, Equals, true)
+    c.Check(move, Equals, Position{2, 3})
+  }
+}
+
+func (s *S) TestHeavyPolicySaveByCapture(c *C) {
+  // The white stone at the edge can't extend, but it can capture the
+  // black stone in atari.
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 4, 4, 0.0, "xo.x" +
+                                                         ".x.." +
+                                                         "...." +
+                                                         "....")
+  state.last = Position{1, 1}
+  state.has_last = true
+  trackEmpty(state)
+  move, ok := newHeavyPolicy(state).Move(state, WHITE)
+  c.Check(ok, Equals, true)
+  c.Check(move, Equals, Position{1, 0})
+}
+
+func (s *S) TestMatchPattern(c *C) {
+  goban := NewArrayGoban(4, 3)
+  // Enclosing hane, in both colors and upside down.
+  FromString(goban, "xox" +
+                    "..." +
+                    "..." +
+                    "oxo")
+  c.Check(MatchPattern(goban, 1, 1), Equals, true)
+  c.Check(MatchPattern(goban, 2, 1), Equals, true)
+  // An empty neighbourhood never matches.
+  FromString(goban, strings.Repeat(".", 12))
+  c.Check(MatchPattern(goban, 1, 1), Equals, false)
+}
+
+func (s *S) TestHeavyPlayoutAllocs(c *C) {
+  state := newPlayoutState()
+  state.PlayoutPolicy(HEAVY_POLICY)
+  playout := newPlayout(state, nil)
+  Winner(playout.Run(Position{6, 2}, BLACK))
+  allocs := testing.AllocsPerRun(50, func() {
+    Winner(playout.Run(Position{6, 2}, BLACK))
+  })
+  c.Check(allocs, Equals, 0.0)
+}
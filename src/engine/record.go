@@ -0,0 +1,133 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "fmt"
+
+// A move or setup stone in the game record.
+type MoveRecord struct {
+  Color Color
+  Y, X int
+  Pass bool
+  Comment string
+  // Setup stones only: the number of moves played before the setup, and
+  // the color of the point before it.
+  Moves int
+  previous Color
+}
+
+// Summary of the last search, attached as a comment to the move the
+// engine chose.
+type SearchInfo struct {
+  color Color
+  win_rate float32
+  playouts int
+  valid bool
+}
+
+func (s *GameState) GetGoban() Goban {
+  return s.goban
+}
+
+func (s *GameState) GetKomi() float32 {
+  return s.komi
+}
+
+func (s *GameState) GetHandicap() int {
+  return s.handicap
+}
+
+func (s *GameState) GetRules() Ruleset {
+  return s.ruleset()
+}
+
+// Setup stones, in the order they were placed. Several edits of a point
+// between the same two moves are kept as a single one, or dropped if they
+// cancel out.
+func (s *GameState) GetSetup() []MoveRecord {
+  return s.setup
+}
+
+// Moves played since the last setup, in order.
+func (s *GameState) GetRecord() []MoveRecord {
+  return s.record
+}
+
+// The game is over after two consecutive passes.
+func (s *GameState) GameOver() bool {
+  n := len(s.record)
+  return n >= 2 && s.record[n - 1].Pass && s.record[n - 2].Pass
+}
+
+// Final score, positive if black wins, including komi. Dead stones in
+// pass-alive territory are removed before scoring.
+func (s *GameState) FinalScore() float32 {
+  black, white := s.ruleset().Score(s.withoutDeadStones())
+  return black - white - s.komi
+}
+
+// Place or remove a stone without playing a move, as in handicap stones
+// or problem setup. The resulting position replaces the last one in the
+// superko history.
+func (s *GameState) Setup(y, x int, color Color) {
+  s.recordSetup(y, x, color)
+  s.goban.SetColor(y, x, color)
+  if color == EMPTY {
+    addEmpty(s, encode(s.goban, y, x))
+  } else {
+    removeEmpty(s, encode(s.goban, y, x))
+  }
+  s.has_ko = false
+  if len(s.history) > 0 {
+    last := len(s.history) - 1
+    s.history[last].board = hashGoban(s.goban)
+  } else {
+    s.recordPosition(EMPTY)
+  }
+}
+
+// Record a setup edit after the moves played so far, merged with the
+// earlier edits of the same point since the last move.
+func (s *GameState) recordSetup(y, x int, color Color) {
+  moves := len(s.record)
+  for i := len(s.setup) - 1; i >= 0 && s.setup[i].Moves == moves; i-- {
+    if s.setup[i].Y != y || s.setup[i].X != x {
+      continue
+    }
+    if color == s.setup[i].previous {
+      s.setup = append(s.setup[:i], s.setup[i + 1:]...)
+    } else {
+      s.setup[i].Color = color
+    }
+    return
+  }
+  previous := s.goban.GetColor(y, x)
+  if color != previous {
+    s.setup = append(s.setup, MoveRecord{Color: color, Y: y, X: x,
+                                         Moves: moves, previous: previous})
+  }
+}
+
+func (s *GameState) recordMove(y, x int, pass bool, color Color) {
+  move := MoveRecord{Color: color, Y: y, X: x, Pass: pass}
+  if s.search.valid && s.search.color == color {
+    move.Comment = fmt.Sprintf("win rate %.3f, %d playouts",
+                               s.search.win_rate, s.search.playouts)
+  }
+  s.search = SearchInfo{}
+  s.record = append(s.record, move)
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Region restricted search, for local fights. The candidate moves of the
+// search are limited to the points of the region, and optionally the
+// moves of the playouts too, which then only fill the region.
+
+// Restrict the search to the points of region, indexed as the goban. A
+// nil region removes the restriction. A region of another size is
+// rejected, keeping the previous one.
+func (s *GameState) SetRegion(region []bool, playouts bool) bool {
+  if region != nil &&
+     (s.goban == nil || len(region) != s.goban.SizeY() * s.goban.SizeX()) {
+    return false
+  }
+  s.region = region
+  s.region_playouts = playouts && region != nil
+  retrackEmpty(s)
+  return true
+}
+
+func (s *GameState) GetRegion() (region []bool, playouts bool) {
+  return s.region, s.region_playouts
+}
+
+func (s *GameState) inRegion(p int) bool {
+  return s.region == nil || s.region[p]
+}
+
+// Check if the playouts can play at p.
+func (s *GameState) inPlayoutRegion(p int) bool {
+  return !s.region_playouts || s.region[p]
+}
+
+// Remove the moves outside the region.
+func regionMoves(state *GameState, moves []Position) []Position {
+  if state.region == nil {
+    return moves
+  }
+  result := make([]Position, 0, len(moves))
+  for _, move := range moves {
+    if state.inRegion(encode(state.goban, move.y, move.x)) {
+      result = append(result, move)
+    }
+  }
+  return result
+}
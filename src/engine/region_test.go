@@ -0,0 +1,76 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+// The bottom left 3x3 corner of a 9x9 board.
+func cornerRegion() []bool {
+  region := make([]bool, 81)
+  for y := 0; y < 3; y++ {
+    for x := 0; x < 3; x++ {
+      region[y * 9 + x] = true
+    }
+  }
+  return region
+}
+
+func (s *S) TestRegionMoves(c *C) {
+  state := newPlayoutState()
+  moves := GetMoveList(state.goban, BLACK)
+  c.Check(regionMoves(state, moves), HasLen, len(moves))
+  state.SetRegion(cornerRegion(), false)
+  c.Check(regionMoves(state, moves), HasLen, 9)
+  _, playouts := state.GetRegion()
+  c.Check(playouts, Equals, false)
+  state.SetRegion(nil, true)
+  region, playouts := state.GetRegion()
+  c.Check(region, IsNil)
+  c.Check(playouts, Equals, false)
+  // A region of another board size is rejected.
+  c.Check(state.SetRegion(make([]bool, 25), true), Equals, false)
+  region, _ = state.GetRegion()
+  c.Check(region, IsNil)
+  c.Check(state.SetRegion(cornerRegion(), true), Equals, true)
+}
+
+func (s *S) TestPlayoutRegion(c *C) {
+  for _, policy := range []PolicyKind{RANDOM_POLICY, HEAVY_POLICY} {
+    state := newPlayoutState()
+    state.PlayoutPolicy(policy)
+    state.SetRegion(cornerRegion(), true)
+    playout := newPlayout(state, NewReplyTable(9, 9))
+    c.Check(playout.empty, HasLen, 9)
+    for i := 0; i < 20; i++ {
+      result := playout.Run(Position{1, 1}, BLACK)
+      iterateAll(result.goban, func (y, x int) {
+        if y >= 3 || x >= 3 {
+          c.Check(result.goban.GetColor(y, x), Equals,
+                  state.goban.GetColor(y, x))
+        }
+      })
+    }
+  }
+}
+
+func (s *S) TestClearBoardRegion(c *C) {
+  state := newPlayoutState()
+  state.SetRegion(cornerRegion(), true)
+  state.ClearBoard()
+  region, _ := state.GetRegion()
+  c.Check(region, IsNil)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "fmt"
+import "strings"
+
+// Column letters used by GTP, which skip the I.
+const column_letters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+var render_map = map[Color] byte {
+  EMPTY : '.',
+  BLACK : 'X',
+  WHITE : 'O',
+  INVALID : '#',
+}
+
+// Name of a column in GTP style. Columns after Z use two letters.
+func ColumnName(x int) string {
+  letters := len(column_letters)
+  if x < letters {
+    return column_letters[x:x + 1]
+  }
+  last := x % letters
+  return ColumnName(x / letters - 1) + column_letters[last:last + 1]
+}
+
+// Inverse of ColumnName, case insensitive.
+func ColumnIndex(name string) (int, bool) {
+  if name == "" {
+    return 0, false
+  }
+  x := -1
+  for _, letter := range strings.ToUpper(name) {
+    index := strings.IndexRune(column_letters, letter)
+    if index < 0 {
+      return 0, false
+    }
+    x = (x + 1) * len(column_letters) + index
+  }
+  return x, true
+}
+
+func columnHeader(g Goban, width int) string {
+  header := strings.Repeat(" ", width)
+  for i := 0; i < g.SizeX(); i++ {
+    header += fmt.Sprintf(" %-*s", len(ColumnName(g.SizeX() - 1)),
+                          ColumnName(i))
+  }
+  return header + "\n"
+}
+
+// Render the board with the highest row at the top, as seen in GTP
+// coordinates. The last move, if any, is marked with parentheses.
+func renderGoban(g Goban, last *Position, notes []string) string {
+  width := len(fmt.Sprint(g.SizeY()))
+  cell := len(ColumnName(g.SizeX() - 1))
+  output := columnHeader(g, width)
+  for j := g.SizeY() - 1; j >= 0; j-- {
+    row := []byte(strings.Repeat(" ", g.SizeX() * (cell + 1) + 1))
+    for i := 0; i < g.SizeX(); i++ {
+      pos := i * (cell + 1) + 1
+      row[pos] = render_map[g.GetColor(j, i)]
+      if last != nil && last.y == j && last.x == i {
+        row[pos - 1] = '('
+        row[pos + 1] = ')'
+      }
+    }
+    line := fmt.Sprintf("%*d%s%d", width, j + 1, row, j + 1)
+    if note := g.SizeY() - 1 - j; note < len(notes) {
+      line += "     " + notes[note]
+    }
+    output += line + "\n"
+  }
+  return output + columnHeader(g, width)
+}
+
+func RenderGoban(g Goban) string {
+  return renderGoban(g, nil, nil)
+}
+
+// Render the board with the last move and the capture counts.
+func RenderBoard(state *GameState) string {
+  var last *Position
+  if n := len(state.record); n > 0 && !state.record[n - 1].Pass {
+    last = &Position{state.record[n - 1].Y, state.record[n - 1].X}
+  }
+  notes := []string{
+    fmt.Sprintf("WHITE (O) has captured %d stones", state.captured_black),
+    fmt.Sprintf("BLACK (X) has captured %d stones", state.captured_white),
+  }
+  return renderGoban(state.goban, last, notes)
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestColumnName(c *C) {
+  c.Check(ColumnName(0), Equals, "A")
+  c.Check(ColumnName(7), Equals, "H")
+  c.Check(ColumnName(8), Equals, "J")
+  c.Check(ColumnName(24), Equals, "Z")
+  c.Check(ColumnName(25), Equals, "AA")
+  c.Check(ColumnName(51), Equals, "BB")
+  for x := 0; x < 700; x++ {
+    index, ok := ColumnIndex(ColumnName(x))
+    c.Check(ok, Equals, true)
+    c.Check(index, Equals, x)
+  }
+  _, ok := ColumnIndex("I")
+  c.Check(ok, Equals, false)
+}
+
+func (s *S) TestRenderBoard(c *C) {
+  state := NewGameState(3, 4, 0.0, "x..." +
+                                   ".o.." +
+                                   "....")
+  state.Play(2, 3, WHITE)
+  c.Check(RenderBoard(state), Equals,
+          "  A B C D\n" +
+          "3 . . .(O)3     WHITE (O) has captured 0 stones\n" +
+          "2 . O . . 2     BLACK (X) has captured 0 stones\n" +
+          "1 X . . . 1\n" +
+          "  A B C D\n")
+}
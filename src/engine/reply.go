@@ -0,0 +1,130 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "sync/atomic"
+
+// --------------------------
+// Last good reply with forgetting (LGRF-1 and LGRF-2).
+//
+// After each playout, every move of the winner is stored as the reply to
+// the previous move, and to the previous two moves. Moves of the loser
+// are removed from the table if they were stored there. The table is
+// shared by all workers of a search, so entries are read and written
+// atomically.
+
+// A move of a playout, with pass encoded as the board area.
+type playoutMove struct {
+  color Color
+  point int
+}
+
+const no_reply = -1
+
+type ReplyTable struct {
+  area int
+  // Indexed by color, then by the previous move, or the previous two.
+  reply1 [2][]int32
+  reply2 [2][]int32
+}
+
+func NewReplyTable(size_y, size_x int) *ReplyTable {
+  t := new(ReplyTable)
+  t.area = size_y * size_x
+  for i := 0; i < 2; i++ {
+    t.reply1[i] = make([]int32, t.area + 1)
+    t.reply2[i] = make([]int32, (t.area + 1) * (t.area + 1))
+    for j := range t.reply1[i] {
+      t.reply1[i][j] = no_reply
+    }
+    for j := range t.reply2[i] {
+      t.reply2[i][j] = no_reply
+    }
+  }
+  return t
+}
+
+func replyIndex(color Color) int {
+  return int(color) - BLACK
+}
+
+// Store the replies of the winner and forget the replies of the loser.
+func (t *ReplyTable) Update(moves []playoutMove, winner Color) {
+  for i := 1; i < len(moves); i++ {
+    move := moves[i]
+    if move.point == t.area {
+      continue
+    }
+    color := replyIndex(move.color)
+    entry1 := &t.reply1[color][moves[i - 1].point]
+    var entry2 *int32
+    if i >= 2 {
+      previous := moves[i - 2].point * (t.area + 1) + moves[i - 1].point
+      entry2 = &t.reply2[color][previous]
+    }
+    if move.color == winner {
+      atomic.StoreInt32(entry1, int32(move.point))
+      if entry2 != nil {
+        atomic.StoreInt32(entry2, int32(move.point))
+      }
+    } else {
+      atomic.CompareAndSwapInt32(entry1, int32(move.point), no_reply)
+      if entry2 != nil {
+        atomic.CompareAndSwapInt32(entry2, int32(move.point), no_reply)
+      }
+    }
+  }
+}
+
+// Replies for color after the given moves, LGRF-2 first, or no_reply.
+func (t *ReplyTable) Replies(moves []playoutMove, color Color) (
+    reply2, reply1 int) {
+  reply2, reply1 = no_reply, no_reply
+  n := len(moves)
+  if n >= 1 {
+    index := replyIndex(color)
+    reply1 = int(atomic.LoadInt32(&t.reply1[index][moves[n - 1].point]))
+    if n >= 2 {
+      previous := moves[n - 2].point * (t.area + 1) + moves[n - 1].point
+      reply2 = int(atomic.LoadInt32(&t.reply2[index][previous]))
+    }
+  }
+  return reply2, reply1
+}
+
+// --------------------------
+// Policy that tries the stored replies before the base policy.
+
+type replyPolicy struct {
+  replies *ReplyTable
+  base Policy
+}
+
+func (r replyPolicy) Move(state *GameState, color Color) (Position, bool) {
+  reply2, reply1 := r.replies.Replies(state.sequence, color)
+  for _, reply := range []int{reply2, reply1} {
+    if reply == no_reply {
+      continue
+    }
+    y, x := decode(state.goban, reply)
+    if state.goban.GetColor(y, x) == EMPTY && state.inPlayoutRegion(reply) &&
+       validMove(state.goban, y, x, color) {
+      return Position{y, x}, true
+    }
+  }
+  return r.base.Move(state, color)
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+import "strings"
+import "testing"
+
+func (s *S) TestReplyTable(c *C) {
+  table := NewReplyTable(3, 3)
+  moves := []playoutMove{{BLACK, 4}, {WHITE, 1}, {BLACK, 7}}
+  table.Update(moves, BLACK)
+  reply2, reply1 := table.Replies(moves[:2], BLACK)
+  c.Check(reply2, Equals, 7)
+  c.Check(reply1, Equals, 7)
+  // White lost, so its reply to the first move isn't stored.
+  _, reply1 = table.Replies(moves[:1], WHITE)
+  c.Check(reply1, Equals, no_reply)
+  // Forget the replies when black loses with them.
+  table.Update(moves, WHITE)
+  reply2, reply1 = table.Replies(moves[:2], BLACK)
+  c.Check(reply2, Equals, no_reply)
+  c.Check(reply1, Equals, no_reply)
+  _, reply1 = table.Replies(moves[:1], WHITE)
+  c.Check(reply1, Equals, 1)
+}
+
+func (s *S) TestReplyPolicy(c *C) {
+  state := NewGameStateWithGoban(CHAIN_GOBAN, 3, 3, 0.0,
+                                 strings.Repeat(".", 9))
+  trackEmpty(state)
+  table := NewReplyTable(3, 3)
+  table.Update([]playoutMove{{WHITE, 4}, {BLACK, 2}}, BLACK)
+  state.sequence = []playoutMove{{WHITE, 4}}
+  policy := replyPolicy{table, RandomPolicy{}}
+  move, ok := policy.Move(state, BLACK)
+  c.Check(ok, Equals, true)
+  c.Check(move, Equals, Position{0, 2})
+  // The reply is ignored when the point is taken.
+  Play(state, 0, 2, WHITE)
+  move, _ = policy.Move(state, BLACK)
+  c.Check(move, Not(Equals), Position{0, 2})
+}
+
+// Replies are stored by the color after the previous move, so the
+// colors of a playout must alternate, passes included.
+func (s *S) TestPlayoutSequenceAlternates(c *C) {
+  for _, policy := range []PolicyKind{RANDOM_POLICY, HEAVY_POLICY} {
+    state := newPlayoutState()
+    state.PlayoutPolicy(policy)
+    playout := newPlayout(state, NewReplyTable(9, 9))
+    for i := 0; i < 20; i++ {
+      sequence := playout.Run(Position{6, 2}, BLACK).sequence
+      c.Assert(sequence[0], Equals, playoutMove{BLACK, 6 * 9 + 2})
+      for j := 1; j < len(sequence); j++ {
+        c.Check(sequence[j].color, Equals, Opposite(sequence[j - 1].color))
+      }
+    }
+  }
+}
+
+func (s *S) TestReplyPlayoutAllocs(c *C) {
+  state := newPlayoutState()
+  table := NewReplyTable(9, 9)
+  playout := newPlayout(state, table)
+  table.Update(playout.Run(Position{6, 2}, BLACK).sequence, BLACK)
+  allocs := testing.AllocsPerRun(50, func() {
+    result := playout.Run(Position{6, 2}, BLACK)
+    table.Update(result.sequence, Winner(result))
+  })
+  c.Check(allocs, Equals, 0.0)
+}
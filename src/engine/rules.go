@@ -0,0 +1,296 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "hash/fnv"
+
+// Possible ko rules.
+const (
+  SIMPLE_KO = iota
+  POSITIONAL_SUPERKO
+  SITUATIONAL_SUPERKO
+)
+
+type KoRule int
+
+// Generic ruleset interface. Score doesn't include the komi.
+type Ruleset interface {
+  Name() string
+  Score(state *GameState) (black, white float32)
+  SuicideAllowed() bool
+  KoRule() KoRule
+}
+
+// --------------------------
+// Scoring methods.
+
+// Count stones and territory of each color. An empty region is territory
+// of a color if it only touches stones of that color.
+func CountArea(g Goban) (stones, territory []int) {
+  s, t := countArea(g, make([]bool, g.SizeY() * g.SizeX()), nil)
+  return s[:], t[:]
+}
+
+// Same as CountArea, using a caller provided buffer for the visited
+// points, so that scoring a playout doesn't allocate. Empty regions next
+// to the stones marked in seki, if any, are not territory.
+func countArea(g Goban, visited []bool, seki []bool) (
+    stones, territory [4]int) {
+  for i := range visited {
+    visited[i] = false
+  }
+  iterateAll(g, func (y, x int) {
+    color := g.GetColor(y, x)
+    if color != EMPTY {
+      stones[color]++
+      return
+    }
+    if visited[y * g.SizeX() + x] {
+      return
+    }
+    size := 0
+    var borders [4]bool
+    neutral := false
+    iterateGroup(g, y, x, func (ny, nx int) {
+      visited[ny * g.SizeX() + nx] = true
+      size++
+    }, func (ny, nx int) {
+      borders[g.GetColor(ny, nx)] = true
+      if seki != nil && seki[ny * g.SizeX() + nx] {
+        neutral = true
+      }
+    })
+    switch {
+    case neutral:
+    case borders[BLACK] && !borders[WHITE]:
+      territory[BLACK] += size
+    case borders[WHITE] && !borders[BLACK]:
+      territory[WHITE] += size
+    }
+  })
+  return stones, territory
+}
+
+// The visited buffer of the state, allocated on first use.
+func (s *GameState) visitedBuffer() []bool {
+  size := s.goban.SizeY() * s.goban.SizeX()
+  if len(s.visited) != size {
+    s.visited = make([]bool, size)
+  }
+  return s.visited
+}
+
+// Count the area of the state, reusing its visited buffer.
+func (s *GameState) countArea() (stones, territory [4]int) {
+  return countArea(s.goban, s.visitedBuffer(), nil)
+}
+
+// Count the area, leaving the eyes of groups in seki as neutral points.
+// The seki buffer is reused, so that scoring doesn't allocate.
+func (s *GameState) countSekiArea() (stones, territory [4]int) {
+  visited := s.visitedBuffer()
+  if len(s.seki) != len(visited) {
+    s.seki = make([]bool, len(visited))
+  }
+  markSeki(s.goban, s.seki, visited)
+  return countArea(s.goban, visited, s.seki)
+}
+
+// Area scoring: stones plus territory.
+type AreaScoring struct{}
+
+func (AreaScoring) Score(state *GameState) (black, white float32) {
+  stones, territory := state.countArea()
+  return float32(stones[BLACK] + territory[BLACK]),
+         float32(stones[WHITE] + territory[WHITE])
+}
+
+// Territory scoring: territory plus prisoners. The eyes of groups in seki
+// are not territory.
+type TerritoryScoring struct{}
+
+func (TerritoryScoring) Score(state *GameState) (black, white float32) {
+  _, territory := state.countSekiArea()
+  return float32(territory[BLACK] + state.captured_white),
+         float32(territory[WHITE] + state.captured_black)
+}
+
+// --------------------------
+// Rulesets.
+
+type ChineseRules struct {
+  AreaScoring
+}
+
+func (ChineseRules) Name() string {
+  return "chinese"
+}
+
+func (ChineseRules) SuicideAllowed() bool {
+  return false
+}
+
+func (ChineseRules) KoRule() KoRule {
+  return POSITIONAL_SUPERKO
+}
+
+type JapaneseRules struct {
+  TerritoryScoring
+}
+
+func (JapaneseRules) Name() string {
+  return "japanese"
+}
+
+func (JapaneseRules) SuicideAllowed() bool {
+  return false
+}
+
+func (JapaneseRules) KoRule() KoRule {
+  return SIMPLE_KO
+}
+
+type AGARules struct {
+  AreaScoring
+}
+
+func (AGARules) Name() string {
+  return "aga"
+}
+
+func (AGARules) SuicideAllowed() bool {
+  return false
+}
+
+func (AGARules) KoRule() KoRule {
+  return SITUATIONAL_SUPERKO
+}
+
+type NewZealandRules struct {
+  AreaScoring
+}
+
+func (NewZealandRules) Name() string {
+  return "new_zealand"
+}
+
+func (NewZealandRules) SuicideAllowed() bool {
+  return true
+}
+
+func (NewZealandRules) KoRule() KoRule {
+  return SITUATIONAL_SUPERKO
+}
+
+var rulesets = map[string] Ruleset {
+  "chinese" : ChineseRules{},
+  "japanese" : JapaneseRules{},
+  "aga" : AGARules{},
+  "new_zealand" : NewZealandRules{},
+}
+
+// Find a ruleset by the names used in the kgs-rules GTP command.
+func RulesetByName(name string) (Ruleset, bool) {
+  rules, ok := rulesets[name]
+  return rules, ok
+}
+
+func (s *GameState) Rules(rules Ruleset) {
+  s.rules = rules
+}
+
+// Chinese rules are used when none was selected.
+func (s *GameState) ruleset() Ruleset {
+  if s.rules == nil {
+    return ChineseRules{}
+  }
+  return s.rules
+}
+
+// --------------------------
+// Move legality.
+
+// A position seen during the game, and the player who moved into it.
+type PositionHash struct {
+  board uint64
+  player Color
+}
+
+func hashGoban(g Goban) uint64 {
+  hash := fnv.New64a()
+  buf := make([]byte, 1)
+  iterateAll(g, func (y, x int) {
+    buf[0] = byte(g.GetColor(y, x))
+    hash.Write(buf)
+  })
+  return hash.Sum64()
+}
+
+func (s *GameState) recordPosition(player Color) {
+  s.history = append(s.history, PositionHash{hashGoban(s.goban), player})
+}
+
+func repeatsPosition(state *GameState, position PositionHash) bool {
+  situational := state.ruleset().KoRule() == SITUATIONAL_SUPERKO
+  for _, old := range state.history {
+    if old.board == position.board &&
+       (!situational || old.player == position.player) {
+      return true
+    }
+  }
+  return false
+}
+
+func IsLegal(state *GameState, y, x int, color Color) bool {
+  g := state.goban
+  if !valid(y, x, g.SizeY(), g.SizeX()) || g.GetColor(y, x) != EMPTY {
+    return false
+  }
+  if state.has_ko && state.ko_color == color && state.ko == (Position{y, x}) {
+    return false
+  }
+  if Suicide(g, y, x, color) {
+    // Single stone suicide is never allowed, since it doesn't change
+    // the position.
+    alone := true
+    iterateNeighbours(g, y, x, func (ny, nx int) {
+      if g.GetColor(ny, nx) == color {
+        alone = false
+      }
+    })
+    if alone || !state.ruleset().SuicideAllowed() {
+      return false
+    }
+  }
+  if state.ruleset().KoRule() == SIMPLE_KO {
+    return true
+  }
+  next := copyState(state)
+  next.goban.SetStack(g.GetStack())
+  Play(next, y, x, color)
+  return !repeatsPosition(state, PositionHash{hashGoban(next.goban), color})
+}
+
+func legalMoves(state *GameState, moves []Position, color Color) []Position {
+  legal := make([]Position, 0, len(moves))
+  for _, move := range moves {
+    if IsLegal(state, move.y, move.x, color) {
+      legal = append(legal, move)
+    }
+  }
+  return legal
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestScoring(c *C) {
+  state := NewGameState(3, 4, 0.0, "x.o." +
+                                   "x.o." +
+                                   "x.o.")
+  state.captured_white = 2
+  state.captured_black = 1
+  black, white := ChineseRules{}.Score(state)
+  c.Check(black, Equals, float32(3))
+  c.Check(white, Equals, float32(6))
+  black, white = JapaneseRules{}.Score(state)
+  c.Check(black, Equals, float32(2))
+  c.Check(white, Equals, float32(4))
+}
+
+func (s *S) TestSimpleKo(c *C) {
+  state := NewGameState(3, 4, 0.0, ".xo." +
+                                   "xo.o" +
+                                   ".xo.")
+  state.Rules(JapaneseRules{})
+  Play(state, 1, 2, BLACK)
+  c.Check(ToString(state.goban), Equals, ".xo." +
+                                         "x.xo" +
+                                         ".xo.")
+  c.Check(IsLegal(state, 1, 1, WHITE), Equals, false)
+  Play(state, 0, 3, WHITE)
+  c.Check(IsLegal(state, 1, 1, WHITE), Equals, true)
+}
+
+func (s *S) TestSuperko(c *C) {
+  state := NewGameState(3, 4, 0.0, ".xo." +
+                                   "xo.o" +
+                                   ".xo.")
+  state.recordPosition(WHITE)
+  c.Check(state.Play(1, 2, BLACK), Equals, true)
+  state.Pass(WHITE)
+  state.Pass(BLACK)
+  // The simple ko is gone, but retaking repeats a previous position.
+  c.Check(IsLegal(state, 1, 1, WHITE), Equals, false)
+}
+
+func (s *S) TestSuicideRules(c *C) {
+  chinese := NewGameState(3, 3, 0.0, "oo." +
+                                     "xxx" +
+                                     "...")
+  c.Check(IsLegal(chinese, 0, 2, WHITE), Equals, false)
+
+  nz := NewGameState(3, 3, 0.0, "oo." +
+                                "xxx" +
+                                "...")
+  nz.Rules(NewZealandRules{})
+  c.Check(IsLegal(nz, 0, 2, WHITE), Equals, true)
+  c.Check(nz.Play(0, 2, WHITE), Equals, true)
+  c.Check(ToString(nz.goban), Equals, "..." +
+                                      "xxx" +
+                                      "...")
+  c.Check(nz.captured_white, Equals, 3)
+
+  single := NewGameState(2, 2, 0.0, ".x" +
+                                    "x.")
+  single.Rules(NewZealandRules{})
+  c.Check(IsLegal(single, 0, 0, WHITE), Equals, false)
+}
+
+func (s *S) TestRulesetByName(c *C) {
+  for _, name := range []string{"chinese", "japanese", "aga", "new_zealand"} {
+    rules, ok := RulesetByName(name)
+    c.Check(ok, Equals, true)
+    c.Check(rules.Name(), Equals, name)
+  }
+  _, ok := RulesetByName("ing")
+  c.Check(ok, Equals, false)
+}
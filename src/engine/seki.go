@@ -0,0 +1,173 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+// --------------------------
+// Seki detection.
+//
+// Only the small sekis are recognized: two groups of different colors,
+// each with exactly two liberties, sharing at least one of them, where
+// every liberty not shared is an eye. Filling a shared liberty puts the
+// filling group in atari, so neither side should play there.
+
+// Append the distinct liberties of the group at y, x, up to limit.
+func appendLiberties(g Goban, y, x int, libs []Position,
+                     limit int) []Position {
+  iterateGroup(g, y, x, func (ny, nx int) {}, func (ny, nx int) {
+    if len(libs) >= limit || g.GetColor(ny, nx) != EMPTY {
+      return
+    }
+    for _, lib := range libs {
+      if lib.y == ny && lib.x == nx {
+        return
+      }
+    }
+    libs = append(libs, Position{ny, nx})
+  })
+  return libs
+}
+
+// Check if the group at y, x is in seki: it has two liberties, and so has
+// an opponent group next to one of them. Each liberty of both groups must
+// be shared, or be an eye of the group, otherwise filling the outside
+// liberty wins the semeai.
+func InSeki(g Goban, y, x int) bool {
+  var buffer [3]Position
+  libs := appendLiberties(g, y, x, buffer[:0], 3)
+  if len(libs) != 2 {
+    return false
+  }
+  color := g.GetColor(y, x)
+  opponent := Opposite(color)
+  for _, lib := range libs {
+    seki := false
+    iterateNeighbours(g, lib.y, lib.x, func (ny, nx int) {
+      if !seki && g.GetColor(ny, nx) == opponent {
+        var other [3]Position
+        other_libs := appendLiberties(g, ny, nx, other[:0], 3)
+        seki = len(other_libs) == 2 &&
+               sekiLiberties(g, libs, other_libs, color) &&
+               sekiLiberties(g, other_libs, libs, opponent)
+      }
+    })
+    if seki {
+      return true
+    }
+  }
+  return false
+}
+
+// Check if each liberty is shared with the opponent or is an eye of color.
+func sekiLiberties(g Goban, libs, shared []Position, color Color) bool {
+  for _, lib := range libs {
+    if lib == shared[0] || lib == shared[1] {
+      continue
+    }
+    if eye, kind := ClassifyEye(g, lib.y, lib.x); kind == NO_EYE ||
+       eye != color {
+      return false
+    }
+  }
+  return true
+}
+
+// Mark the stones of all groups in seki.
+func SekiPoints(g Goban) []bool {
+  size := g.SizeY() * g.SizeX()
+  seki := make([]bool, size)
+  markSeki(g, seki, make([]bool, size))
+  return seki
+}
+
+// Mark the stones of all groups in seki, overwriting both buffers. The done
+// buffer avoids checking a group more than once.
+func markSeki(g Goban, seki, done []bool) {
+  for i := range done {
+    seki[i], done[i] = false, false
+  }
+  iterateAll(g, func (y, x int) {
+    color := g.GetColor(y, x)
+    if done[encode(g, y, x)] || (color != BLACK && color != WHITE) {
+      return
+    }
+    in_seki := InSeki(g, y, x)
+    iterateGroup(g, y, x, func (ny, nx int) {
+      done[encode(g, ny, nx)] = true
+      seki[encode(g, ny, nx)] = in_seki
+    }, func (ny, nx int) {})
+  })
+}
+
+// A move breaks a seki if it's a self-atari that doesn't capture, next
+// to a group of each color with two liberties.
+func breaksSeki(g Goban, y, x int, color Color) bool {
+  own, other := false, false
+  captures := false
+  var neighbours [4]int
+  iterateNeighbours(g, y, x, func (ny, nx int) {
+    neighbours[g.GetColor(ny, nx)]++
+  })
+  // Two empty neighbours are already two liberties.
+  if neighbours[EMPTY] >= 2 || neighbours[color] == 0 ||
+     neighbours[Opposite(color)] == 0 {
+    return false
+  }
+  iterateNeighbours(g, y, x, func (ny, nx int) {
+    switch g.GetColor(ny, nx) {
+    case color:
+      own = own || countLiberties(g, ny, nx, 3) == 2
+    case Opposite(color):
+      switch countLiberties(g, ny, nx, 3) {
+      case 1:
+        captures = true
+      case 2:
+        other = true
+      }
+    }
+  })
+  if captures || !own || !other {
+    return false
+  }
+  // Liberties of the group formed by the move.
+  var buffer [2]Position
+  libs := buffer[:0]
+  iterateNeighbours(g, y, x, func (ny, nx int) {
+    switch g.GetColor(ny, nx) {
+    case EMPTY:
+      if len(libs) < 2 {
+        libs = appendUnique(libs, Position{ny, nx})
+      }
+    case color:
+      var group [3]Position
+      for _, lib := range appendLiberties(g, ny, nx, group[:0], 3) {
+        if len(libs) < 2 && (lib.y != y || lib.x != x) {
+          libs = appendUnique(libs, lib)
+        }
+      }
+    }
+  })
+  return len(libs) == 1
+}
+
+func appendUnique(positions []Position, p Position) []Position {
+  for _, q := range positions {
+    if q == p {
+      return positions
+    }
+  }
+  return append(positions, p)
+}
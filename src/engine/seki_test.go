@@ -0,0 +1,92 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+import "testing"
+
+// Each side has one eye, and they share the liberty in between.
+const seki_board = ".x.o." +
+                   "xxxoo" +
+                   "ooooo"
+
+func (s *S) TestInSeki(c *C) {
+  goban := NewChainGoban(3, 5)
+  FromString(goban, seki_board)
+  c.Check(InSeki(goban, 0, 1), Equals, true)
+  c.Check(InSeki(goban, 2, 0), Equals, true)
+  seki := SekiPoints(goban)
+  c.Check(seki[encode(goban, 1, 1)], Equals, true)
+  c.Check(seki[encode(goban, 0, 0)], Equals, false)
+  // With a third liberty, black is alive.
+  FromString(goban, ".x..." +
+                    "xxxoo" +
+                    "ooooo")
+  c.Check(InSeki(goban, 0, 1), Equals, false)
+}
+
+func (s *S) TestInSekiSemeai(c *C) {
+  // White's second liberty isn't an eye: playing there captures two
+  // stones and wins the semeai.
+  goban := NewChainGoban(3, 6)
+  FromString(goban, ".x.o.x" +
+                    "xxxoox" +
+                    "oooooo")
+  c.Check(InSeki(goban, 0, 1), Equals, false)
+  c.Check(InSeki(goban, 0, 3), Equals, false)
+  // Both liberties are shared.
+  goban = NewChainGoban(2, 3)
+  FromString(goban, "x.o" +
+                    "x.o")
+  c.Check(InSeki(goban, 0, 0), Equals, true)
+  c.Check(InSeki(goban, 0, 2), Equals, true)
+}
+
+func (s *S) TestSekiScoreAllocs(c *C) {
+  state := NewGameState(3, 5, 0.0, seki_board)
+  // The first call allocates the scoring buffers.
+  JapaneseRules{}.Score(state)
+  allocs := testing.AllocsPerRun(50, func() {
+    JapaneseRules{}.Score(state)
+  })
+  c.Check(allocs, Equals, 0.0)
+}
+
+func (s *S) TestBreaksSeki(c *C) {
+  goban := NewChainGoban(3, 5)
+  FromString(goban, seki_board)
+  for _, color := range []Color{BLACK, WHITE} {
+    c.Check(breaksSeki(goban, 0, 2, color), Equals, true)
+    c.Check(validMove(goban, 0, 2, color), Equals, false)
+  }
+  FromString(goban, ".x..." +
+                    "xxxoo" +
+                    "ooooo")
+  c.Check(breaksSeki(goban, 0, 2, BLACK), Equals, false)
+  c.Check(breaksSeki(goban, 0, 3, WHITE), Equals, false)
+}
+
+func (s *S) TestSekiScore(c *C) {
+  state := NewGameState(3, 5, 0.0, seki_board)
+  // Area scoring counts the eyes in seki, territory scoring doesn't.
+  black, white := ChineseRules{}.Score(state)
+  c.Check(black, Equals, float32(5))
+  c.Check(white, Equals, float32(9))
+  black, white = JapaneseRules{}.Score(state)
+  c.Check(black, Equals, float32(0))
+  c.Check(white, Equals, float32(0))
+}
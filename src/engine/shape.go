@@ -0,0 +1,80 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "errors"
+import "fmt"
+import "io"
+import "io/ioutil"
+import "strings"
+
+// --------------------------
+// Irregular boards. A shape is drawn with one line per row, using the
+// same characters as FromString, with '#' marking points that are not
+// part of the board:
+//
+//   ##...##
+//   #.....#
+//   .......
+//   #.....#
+//   ##...##
+
+// Parse a shape into its dimensions and a string for FromString.
+func ParseShape(shape string) (rows, cols int, init string, err error) {
+  lines := make([]string, 0)
+  for _, line := range strings.Split(shape, "\n") {
+    line = strings.TrimRight(line, " \t\r")
+    if line != "" {
+      lines = append(lines, line)
+    }
+  }
+  if len(lines) == 0 {
+    return 0, 0, "", errors.New("engine: empty shape")
+  }
+  cols = len(lines[0])
+  for j, line := range lines {
+    if len(line) != cols {
+      return 0, 0, "", fmt.Errorf("engine: shape line %d has %d points, " +
+                                  "expected %d", j + 1, len(line), cols)
+    }
+    for i := 0; i < len(line); i++ {
+      if _, ok := ascii_map[line[i]]; !ok {
+        return 0, 0, "", fmt.Errorf("engine: invalid shape character %q",
+                                    line[i])
+      }
+    }
+  }
+  return len(lines), cols, strings.Join(lines, ""), nil
+}
+
+func NewShapedGameState(kind GobanKind, shape string, komi float32) (
+    *GameState, error) {
+  rows, cols, init, err := ParseShape(shape)
+  if err != nil {
+    return nil, err
+  }
+  return NewGameStateWithGoban(kind, rows, cols, komi, init), nil
+}
+
+func LoadShape(kind GobanKind, reader io.Reader, komi float32) (
+    *GameState, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+  return NewShapedGameState(kind, string(data), komi)
+}
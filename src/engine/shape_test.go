@@ -0,0 +1,80 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+const cross = "#.#\n" +
+              "...\n" +
+              "#.#\n"
+
+var goban_kinds = []GobanKind{SLICE_GOBAN, ARRAY_GOBAN, CHAIN_GOBAN,
+                              BITBOARD_GOBAN, PADDED_GOBAN}
+
+func (s *S) TestParseShape(c *C) {
+  rows, cols, init, err := ParseShape("\n##..\r\n.x#o  \n\n")
+  c.Check(err, IsNil)
+  c.Check(rows, Equals, 2)
+  c.Check(cols, Equals, 4)
+  c.Check(init, Equals, "##...x#o")
+
+  _, _, _, err = ParseShape("...\n..\n")
+  c.Check(err, NotNil)
+  _, _, _, err = ParseShape("..?\n")
+  c.Check(err, NotNil)
+  _, _, _, err = ParseShape("")
+  c.Check(err, NotNil)
+}
+
+func (s *S) TestShapedBoard(c *C) {
+  for _, kind := range goban_kinds {
+    state, err := NewShapedGameState(kind, cross, 0.0)
+    c.Assert(err, IsNil)
+    c.Check(state.goban.GetColor(0, 0), Equals, Color(INVALID))
+    c.Check(GetMoveList(state.goban, BLACK), DeepEquals,
+            []Position{{0, 1}, {1, 0}, {1, 1}, {1, 2}, {2, 1}})
+
+    // The center has only the four arms as neighbours, and each arm
+    // has only the center.
+    Play(state, 1, 1, BLACK)
+    c.Check(CountLiberties(state.goban, 1, 1), Equals, 4)
+    c.Check(Suicide(state.goban, 0, 1, WHITE), Equals, true)
+    RemoveGroup(state.goban, 1, 1)
+    Play(state, 0, 1, BLACK)
+    c.Check(inAtari(state.goban, 0, 1), Equals, true)
+    Play(state, 1, 1, WHITE)
+    c.Check(ToString(state.goban), Equals, "#.#" +
+                                           ".o." +
+                                           "#.#")
+    c.Check(state.captured_black, Equals, 1)
+    black, white := ChineseRules{}.Score(state)
+    c.Check(black, Equals, float32(0))
+    c.Check(white, Equals, float32(5))
+  }
+}
+
+func (s *S) TestClearShapedBoard(c *C) {
+  for _, kind := range goban_kinds {
+    state, err := NewShapedGameState(kind, cross, 0.0)
+    c.Assert(err, IsNil)
+    Play(state, 1, 1, BLACK)
+    state.ClearBoard()
+    c.Check(ToString(state.goban), Equals, "#.#" +
+                                           "..." +
+                                           "#.#")
+  }
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import "hash/fnv"
+
+// --------------------------
+// Board symmetries.
+//
+// A square board has 8 symmetries, its rotations and reflections, and
+// other boards only have the 4 that keep their sizes. A symmetry is a
+// set of bits, applied in order: mirror the columns, mirror the rows,
+// then swap rows and columns. Symmetric positions share a canonical hash,
+// so transposition tables and opening books can keep a single entry for
+// all of them.
+
+type Symmetry int
+
+const IDENTITY Symmetry = 0
+
+const (
+  MIRROR_X Symmetry = 1 << iota
+  MIRROR_Y
+  TRANSPOSE
+)
+
+// The symmetries of a board of the given size, starting with the
+// identity.
+func Symmetries(size_y, size_x int) []Symmetry {
+  count := 4
+  if size_y == size_x {
+    count = 8
+  }
+  symmetries := make([]Symmetry, count)
+  for i := range symmetries {
+    symmetries[i] = Symmetry(i)
+  }
+  return symmetries
+}
+
+// Transform the point y, x of a board of the given size.
+func (s Symmetry) Point(size_y, size_x, y, x int) (int, int) {
+  if s & MIRROR_X != 0 {
+    x = size_x - 1 - x
+  }
+  if s & MIRROR_Y != 0 {
+    y = size_y - 1 - y
+  }
+  if s & TRANSPOSE != 0 {
+    y, x = x, y
+  }
+  return y, x
+}
+
+// The symmetry that undoes s. Mirrors commute with each other, but
+// moving a mirror to the other side of the transposition changes its
+// axis.
+func (s Symmetry) Inverse() Symmetry {
+  if s & TRANSPOSE == 0 {
+    return s
+  }
+  inverse := TRANSPOSE
+  if s & MIRROR_X != 0 {
+    inverse |= MIRROR_Y
+  }
+  if s & MIRROR_Y != 0 {
+    inverse |= MIRROR_X
+  }
+  return inverse
+}
+
+// Transform a move, which is returned unchanged if it's a pass.
+func (s Symmetry) Move(g Goban, y, x int, pass bool) (int, int, bool) {
+  if pass {
+    return y, x, true
+  }
+  y, x = s.Point(g.SizeY(), g.SizeX(), y, x)
+  return y, x, false
+}
+
+// A copy of the goban with the symmetry applied to its stones.
+func TransformGoban(g Goban, s Symmetry) Goban {
+  next := g.Copy()
+  iterateAll(next, func (y, x int) {
+    next.SetColor(y, x, EMPTY)
+  })
+  iterateAll(g, func (y, x int) {
+    ty, tx := s.Point(g.SizeY(), g.SizeX(), y, x)
+    next.SetColor(ty, tx, g.GetColor(y, x))
+  })
+  return next
+}
+
+// Hash of the goban after applying the symmetry, the same that
+// hashGoban would give for the transformed goban.
+func symmetricHash(g Goban, s Symmetry) uint64 {
+  hash := fnv.New64a()
+  buf := make([]byte, 1)
+  inverse := s.Inverse()
+  iterateAll(g, func (y, x int) {
+    sy, sx := inverse.Point(g.SizeY(), g.SizeX(), y, x)
+    buf[0] = byte(g.GetColor(sy, sx))
+    hash.Write(buf)
+  })
+  return hash.Sum64()
+}
+
+// The smallest hash among the positions given by applying the symmetries
+// to the goban, and the symmetry that gives the position with that hash.
+// Moves are converted to the canonical orientation with this symmetry,
+// and back with its inverse. Problems that only keep some symmetries of
+// the board, like a tsumego region, pass just those.
+func CanonicalHash(g Goban, symmetries []Symmetry) (uint64, Symmetry) {
+  best, best_symmetry := uint64(0), IDENTITY
+  for i, s := range symmetries {
+    if hash := symmetricHash(g, s); i == 0 || hash < best {
+      best, best_symmetry = hash, s
+    }
+  }
+  return best, best_symmetry
+}
+
+// The symmetries that leave the goban unchanged.
+func Stabilizer(g Goban) []Symmetry {
+  stabilizer := []Symmetry{}
+  for _, s := range Symmetries(g.SizeY(), g.SizeX()) {
+    same := true
+    iterateAll(g, func (y, x int) {
+      sy, sx := s.Point(g.SizeY(), g.SizeX(), y, x)
+      same = same && g.GetColor(sy, sx) == g.GetColor(y, x)
+    })
+    if same {
+      stabilizer = append(stabilizer, s)
+    }
+  }
+  return stabilizer
+}
+
+// Keep a single move among the ones that are equivalent under the
+// symmetries of the position, which cuts the root moves of an empty
+// square board to about an eighth. A ko or a region breaks the symmetry,
+// so those positions keep all moves.
+func symmetricMoves(state *GameState, moves []Position) []Position {
+  if state.has_ko || state.region != nil {
+    return moves
+  }
+  g := state.goban
+  stabilizer := Stabilizer(g)
+  if len(stabilizer) == 1 {
+    return moves
+  }
+  seen := make([]bool, g.SizeY() * g.SizeX())
+  unique := make([]Position, 0, len(moves))
+  for _, move := range moves {
+    if seen[encode(g, move.y, move.x)] {
+      continue
+    }
+    unique = append(unique, move)
+    for _, s := range stabilizer {
+      y, x := s.Point(g.SizeY(), g.SizeX(), move.y, move.x)
+      seen[encode(g, y, x)] = true
+    }
+  }
+  return unique
+}
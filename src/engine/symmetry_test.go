@@ -0,0 +1,81 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package engine
+
+import . "launchpad.net/gocheck"
+
+func (s *S) TestSymmetries(c *C) {
+  c.Check(Symmetries(9, 9), HasLen, 8)
+  c.Check(Symmetries(5, 4), HasLen, 4)
+  for _, symmetry := range Symmetries(5, 5) {
+    inverse := symmetry.Inverse()
+    for y := 0; y < 5; y++ {
+      for x := 0; x < 5; x++ {
+        sy, sx := symmetry.Point(5, 5, y, x)
+        iy, ix := inverse.Point(5, 5, sy, sx)
+        c.Check(Position{iy, ix}, Equals, Position{y, x})
+      }
+    }
+  }
+}
+
+func (s *S) TestTransformGoban(c *C) {
+  state := NewGameState(3, 4, 0.0, "x..." +
+                                   "..o." +
+                                   "....")
+  next := TransformGoban(state.goban, MIRROR_X | MIRROR_Y)
+  c.Check(next.GetColor(2, 3), Equals, Color(BLACK))
+  c.Check(next.GetColor(1, 1), Equals, Color(WHITE))
+  c.Check(next.GetColor(0, 0), Equals, Color(EMPTY))
+  y, x, pass := MIRROR_X.Move(state.goban, 0, 0, false)
+  c.Check(Position{y, x}, Equals, Position{0, 3})
+  c.Check(pass, Equals, false)
+}
+
+func (s *S) TestCanonicalHash(c *C) {
+  state := NewGameState(3, 3, 0.0, "x.." +
+                                   "..o" +
+                                   "...")
+  symmetries := Symmetries(3, 3)
+  hash, symmetry := CanonicalHash(state.goban, symmetries)
+  for _, s := range symmetries {
+    next := TransformGoban(state.goban, s)
+    other, _ := CanonicalHash(next, symmetries)
+    c.Check(other, Equals, hash)
+  }
+  c.Check(hashGoban(TransformGoban(state.goban, symmetry)), Equals, hash)
+  empty := NewGameState(3, 3, 0.0, ".........")
+  c.Check(hash == hashGoban(empty.goban), Equals, false)
+  // Only the identity keeps the hash of the goban.
+  identity, _ := CanonicalHash(state.goban, []Symmetry{IDENTITY})
+  c.Check(identity, Equals, hashGoban(state.goban))
+}
+
+func (s *S) TestSymmetricMoves(c *C) {
+  state := NewEmptyGameState(9, 9)
+  moves := GetMoveList(state.goban, BLACK)
+  c.Check(symmetricMoves(state, moves), HasLen, 15)
+  state = NewEmptyGameState(3, 4)
+  moves = GetMoveList(state.goban, BLACK)
+  c.Check(symmetricMoves(state, moves), HasLen, 4)
+  state = NewGameState(3, 3, 0.0, "x.." +
+                                   "..." +
+                                   "...")
+  moves = GetMoveList(state.goban, WHITE)
+  c.Check(Stabilizer(state.goban), HasLen, 2)
+  c.Check(symmetricMoves(state, moves), HasLen, 5)
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package main
+
+// Solve a tiny board exactly, caching the search on disk. The cache is
+// saved every checkpoint while searching, and when the timeout stops the
+// search, so running again goes on from there.
+//
+//   go run exact.go -rows 3 -cols 3 -komi 9 -cache /tmp
+
+import "exact"
+import "gtp"
+import "flag"
+import "fmt"
+import "os"
+import "strconv"
+import "strings"
+import "time"
+
+func main() {
+  rows := flag.Int("rows", 3, "Rows of the board.")
+  cols := flag.Int("cols", 3, "Columns of the board.")
+  komi := flag.Float64("komi", 0, "Komi given to white.")
+  cache := flag.String("cache", ".",
+                       "Directory of the cache files, or empty for none.")
+  checkpoint := flag.Duration("checkpoint", time.Minute,
+                              "Time between saves of the cache.")
+  timeout := flag.Duration("timeout", 0,
+                           "Time to stop the search, or zero for none.")
+  flag.Parse()
+  solver, err := exact.NewSolver(*rows, *cols)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    os.Exit(2)
+  }
+  name := ""
+  if *cache != "" {
+    name = solver.CacheFile(*cache)
+    if err := solver.Load(name); err != nil {
+      fmt.Fprintln(os.Stderr, err)
+      os.Exit(1)
+    }
+    solver.Interval = *checkpoint
+    solver.Checkpoint = func() {
+      save(solver, name)
+    }
+  }
+  solver.Timeout = *timeout
+  start := time.Now()
+  result, err := solver.Solve()
+  elapsed := time.Since(start)
+  if name != "" {
+    save(solver, name)
+  }
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "stopped after %d nodes in %v: %v\n",
+                solver.Nodes, elapsed, err)
+    os.Exit(1)
+  }
+  score := float64(result.Score) - *komi
+  outcome := "0"
+  switch {
+  case score > 0:
+    outcome = "B+" + strconv.FormatFloat(score, 'f', -1, 64)
+  case score < 0:
+    outcome = "W+" + strconv.FormatFloat(-score, 'f', -1, 64)
+  }
+  moves := make([]string, len(result.Moves))
+  for i, move := range result.Moves {
+    moves[i] = gtp.Vertex{Y: move.Y, X: move.X, Pass: move.Pass}.String()
+  }
+  fmt.Printf("%dx%d: score %d, %s with komi %g\n", *rows, *cols,
+             result.Score, outcome, *komi)
+  fmt.Printf("line: %s\n", strings.Join(moves, " "))
+  fmt.Printf("%d nodes in %v\n", solver.Nodes, elapsed)
+}
+
+func save(solver *exact.Solver, name string) {
+  if err := solver.Save(name); err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    os.Exit(1)
+  }
+}
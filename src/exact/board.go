@@ -0,0 +1,292 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package exact
+
+import "engine"
+import "math/bits"
+
+// --------------------------
+// Tiny boards. Each color is a set of points in the bits of a word, and
+// positions are small enough to be stored as a base 3 number, which is
+// used both as the exact key of the transposition table and to detect
+// repetitions.
+
+// Largest board area the solver accepts, so that its keys fit in 64
+// bits. The search only finishes on much smaller boards.
+const MAX_AREA = 30
+
+// The shape of the board, shared by all its positions.
+type geometry struct {
+  rows, cols, area int
+  // All the points, and the ones in the first and last columns.
+  full, left, right uint64
+  // Permutation of the points for each symmetry of the board, and its
+  // inverse.
+  symmetries, inverses [][]int
+  // Value of a black stone at each point in the code of the position
+  // with each symmetry applied. White stones count twice.
+  weights [][]uint64
+}
+
+type board struct {
+  *geometry
+  stones [3]uint64
+}
+
+func newBoard(rows, cols int) *board {
+  g := &geometry{rows: rows, cols: cols, area: rows * cols}
+  g.full = 1 << uint(g.area) - 1
+  for y := 0; y < rows; y++ {
+    g.left |= 1 << uint(y * cols)
+    g.right |= 1 << uint(y * cols + cols - 1)
+  }
+  powers := make([]uint64, g.area)
+  for p := range powers {
+    powers[p] = 1
+    if p > 0 {
+      powers[p] = powers[p - 1] * 3
+    }
+  }
+  for _, symmetry := range engine.Symmetries(rows, cols) {
+    permutation := make([]int, g.area)
+    weights := make([]uint64, g.area)
+    for p := range permutation {
+      y, x := symmetry.Point(rows, cols, p / cols, p % cols)
+      permutation[p] = y * cols + x
+      weights[p] = powers[permutation[p]]
+    }
+    inverse := make([]int, g.area)
+    for p, target := range permutation {
+      inverse[target] = p
+    }
+    g.symmetries = append(g.symmetries, permutation)
+    g.inverses = append(g.inverses, inverse)
+    g.weights = append(g.weights, weights)
+  }
+  return &board{geometry: g}
+}
+
+func (b *board) copy() *board {
+  next := *b
+  return &next
+}
+
+func (b *board) color(p int) engine.Color {
+  switch {
+  case b.stones[engine.BLACK] & (1 << uint(p)) != 0:
+    return engine.BLACK
+  case b.stones[engine.WHITE] & (1 << uint(p)) != 0:
+    return engine.WHITE
+  }
+  return engine.EMPTY
+}
+
+func (b *board) empty() uint64 {
+  return b.full &^ (b.stones[engine.BLACK] | b.stones[engine.WHITE])
+}
+
+// The points of set and their neighbours.
+func (b *board) expand(set uint64) uint64 {
+  return (set | (set &^ b.right) << 1 | (set &^ b.left) >> 1 |
+          set << uint(b.cols) | set >> uint(b.cols)) & b.full
+}
+
+// The connected part of within that contains the points of seed.
+func (b *board) fill(seed, within uint64) uint64 {
+  for {
+    next := b.expand(seed) & within
+    if next == seed {
+      return seed
+    }
+    seed = next
+  }
+}
+
+// The position as a base 3 number.
+func (b *board) code() uint64 {
+  return b.symmetricCode(0)
+}
+
+// The code of the position with the symmetry applied.
+func (b *board) symmetricCode(symmetry int) uint64 {
+  weights := b.weights[symmetry]
+  code := uint64(0)
+  for set := b.stones[engine.BLACK]; set != 0; set &= set - 1 {
+    code += weights[bits.TrailingZeros64(set)]
+  }
+  for set := b.stones[engine.WHITE]; set != 0; set &= set - 1 {
+    code += 2 * weights[bits.TrailingZeros64(set)]
+  }
+  return code
+}
+
+// The smallest code among the symmetric positions, and the symmetry that
+// gives it.
+func (b *board) canonical() (uint64, int) {
+  best, best_symmetry := b.symmetricCode(0), 0
+  for s := 1; s < len(b.symmetries); s++ {
+    if code := b.symmetricCode(s); code < best {
+      best, best_symmetry = code, s
+    }
+  }
+  return best, best_symmetry
+}
+
+// Convert the code of a position from the orientation that the symmetry
+// from makes canonical to the one that to makes canonical.
+func (b *board) transform(code uint64, from, to int) uint64 {
+  if from == to {
+    return code
+  }
+  var colors [MAX_AREA]engine.Color
+  for p := 0; p < b.area; p++ {
+    colors[b.inverses[to][b.symmetries[from][p]]] = engine.Color(code % 3)
+    code /= 3
+  }
+  for p := b.area - 1; p >= 0; p-- {
+    code = code * 3 + uint64(colors[p])
+  }
+  return code
+}
+
+// Play color at p, returning false if the point is taken or the move is
+// suicide. If the move captured a single stone in a way that the opponent
+// could take back at once, repeating the position, ko is that point, and
+// otherwise it's -1.
+func (b *board) play(p int, color engine.Color) (ko int, ok bool) {
+  stone := uint64(1) << uint(p)
+  if b.empty() & stone == 0 {
+    return -1, false
+  }
+  opponent := engine.Opposite(color)
+  b.stones[color] |= stone
+  captured := uint64(0)
+  for set := b.expand(stone) & b.stones[opponent]; set != 0;
+      set &= set - 1 {
+    group := b.fill(set & -set, b.stones[opponent])
+    if b.expand(group) & b.empty() == 0 {
+      b.stones[opponent] &^= group
+      captured |= group
+    }
+  }
+  group := b.fill(stone, b.stones[color])
+  if b.expand(group) & b.empty() == 0 {
+    b.stones[color] &^= stone
+    return -1, false
+  }
+  // A single stone capture by a lone stone, whose only liberty is the
+  // captured point.
+  if bits.OnesCount64(captured) != 1 || group != stone ||
+     b.expand(stone) & b.empty() != captured {
+    return -1, true
+  }
+  return bits.TrailingZeros64(captured), true
+}
+
+// Area score, black minus white: stones plus the empty regions that only
+// reach one color.
+func (b *board) score() int {
+  black, white := b.stones[engine.BLACK], b.stones[engine.WHITE]
+  score := bits.OnesCount64(black) - bits.OnesCount64(white)
+  for empty := b.empty(); empty != 0; {
+    region := b.fill(empty & -empty, empty)
+    empty &^= region
+    border := b.expand(region)
+    switch {
+    case border & black != 0 && border & white == 0:
+      score += bits.OnesCount64(region)
+    case border & white != 0 && border & black == 0:
+      score -= bits.OnesCount64(region)
+    }
+  }
+  return score
+}
+
+// Points that belong to color whatever is played, as in engine.Benson:
+// its unconditionally alive blocks, and the regions vital to them.
+func (b *board) settled(color engine.Color) uint64 {
+  own, empty := b.stones[color], b.empty()
+  var blocks, regions [MAX_AREA]uint64
+  block_count, region_count := 0, 0
+  for set := own; set != 0; block_count++ {
+    blocks[block_count] = b.fill(set & -set, own)
+    set &^= blocks[block_count]
+  }
+  for set := b.full &^ own; set != 0; region_count++ {
+    regions[region_count] = b.fill(set & -set, b.full &^ own)
+    set &^= regions[region_count]
+  }
+  // The blocks next to each region, and the regions vital to each block,
+  // where all the empty points are liberties of the block.
+  var touching, vital [MAX_AREA]uint32
+  for i := 0; i < block_count; i++ {
+    liberties := b.expand(blocks[i])
+    for r := 0; r < region_count; r++ {
+      if liberties & regions[r] == 0 {
+        continue
+      }
+      touching[r] |= 1 << uint(i)
+      if inside := regions[r] & empty; inside != 0 &&
+         inside &^ liberties == 0 {
+        vital[i] |= 1 << uint(r)
+      }
+    }
+  }
+  live_blocks := uint32(1) << uint(block_count) - 1
+  live_regions := uint32(1) << uint(region_count) - 1
+  for changed := true; changed; {
+    changed = false
+    for i := 0; i < block_count; i++ {
+      if live_blocks & (1 << uint(i)) != 0 &&
+         bits.OnesCount32(vital[i] & live_regions) < 2 {
+        live_blocks &^= 1 << uint(i)
+        changed = true
+      }
+    }
+    for r := 0; r < region_count; r++ {
+      if live_regions & (1 << uint(r)) != 0 &&
+         touching[r] &^ live_blocks != 0 {
+        live_regions &^= 1 << uint(r)
+        changed = true
+      }
+    }
+  }
+  result := uint64(0)
+  for i := 0; i < block_count; i++ {
+    if live_blocks & (1 << uint(i)) == 0 {
+      continue
+    }
+    result |= blocks[i]
+    for r := 0; r < region_count; r++ {
+      if vital[i] & live_regions & (1 << uint(r)) != 0 {
+        result |= regions[r]
+      }
+    }
+  }
+  return result
+}
+
+// Bounds of the final score, black minus white, when the owner of each
+// settled point still has a move to capture the stones inside it. The
+// points not settled could end up for either color.
+func (b *board) bounds() (low, high int) {
+  black := b.settled(engine.BLACK)
+  white := b.settled(engine.WHITE) &^ black
+  score := bits.OnesCount64(black) - bits.OnesCount64(white)
+  open := bits.OnesCount64(b.full &^ black &^ white)
+  return score - open, score + open
+}
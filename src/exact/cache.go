@@ -0,0 +1,121 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package exact
+
+import "bufio"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strconv"
+import "strings"
+
+// --------------------------
+// Transposition tables on disk, one file per board size, so a board is
+// only solved once. Each line holds an entry: the key, the bound, the
+// score, the best move, the symmetry, the visited set and the repeated
+// positions. A key may have several entries, found on different lines
+// of play.
+
+// The cache file for the board of the solver in dir.
+func (s *Solver) CacheFile(dir string) string {
+  return filepath.Join(dir, fmt.Sprintf("exact-%dx%d.cache", s.rows, s.cols))
+}
+
+// Save the table. It is written beside the file first, so stopping
+// halfway keeps the previous one.
+func (s *Solver) Save(name string) error {
+  file, err := os.Create(name + ".tmp")
+  if err != nil {
+    return err
+  }
+  writer := bufio.NewWriter(file)
+  for key, bucket := range s.table {
+    for _, e := range bucket {
+      fmt.Fprintf(writer, "%d %d %d %d %d", key, e.bound, e.score, e.best,
+                  e.symmetry)
+      for _, word := range e.visited {
+        fmt.Fprintf(writer, " %x", word)
+      }
+      for _, code := range e.repeated {
+        fmt.Fprintf(writer, " %d", code)
+      }
+      fmt.Fprintln(writer)
+    }
+  }
+  if err := writer.Flush(); err != nil {
+    file.Close()
+    return err
+  }
+  if err := file.Close(); err != nil {
+    return err
+  }
+  return os.Rename(name + ".tmp", name)
+}
+
+// Load the entries saved in a file. A missing file is an empty cache.
+func (s *Solver) Load(name string) error {
+  file, err := os.Open(name)
+  if os.IsNotExist(err) {
+    return nil
+  }
+  if err != nil {
+    return err
+  }
+  defer file.Close()
+  scanner := bufio.NewScanner(file)
+  for line := 1; scanner.Scan(); line++ {
+    key, e, err := parseEntry(scanner.Text())
+    if err != nil {
+      return fmt.Errorf("%s:%d: %v", name, line, err)
+    }
+    s.table[key] = append(s.table[key], e)
+  }
+  return scanner.Err()
+}
+
+func parseEntry(line string) (uint64, entry, error) {
+  var e entry
+  fields := strings.Fields(line)
+  if len(fields) < 5 + len(e.visited) {
+    return 0, e, fmt.Errorf("short entry")
+  }
+  key, err := strconv.ParseUint(fields[0], 10, 64)
+  if err != nil {
+    return 0, e, err
+  }
+  for i, value := range []*int8{&e.bound, &e.score, &e.best, &e.symmetry} {
+    n, err := strconv.ParseInt(fields[i + 1], 10, 8)
+    if err != nil {
+      return 0, e, err
+    }
+    *value = int8(n)
+  }
+  for i := range e.visited {
+    if e.visited[i], err = strconv.ParseUint(fields[5 + i], 16, 64);
+       err != nil {
+      return 0, e, err
+    }
+  }
+  for _, field := range fields[5 + len(e.visited):] {
+    code, err := strconv.ParseUint(field, 10, 64)
+    if err != nil {
+      return 0, e, err
+    }
+    e.repeated = append(e.repeated, code)
+  }
+  return key, e, nil
+}
@@ -0,0 +1,515 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+// Package exact computes the score of tiny boards under perfect play,
+// with an alpha-beta search of the whole game. The rules are area
+// scoring, positional superko, suicide forbidden, and the game ends after
+// two passes in a row. Komi is left to the caller.
+//
+// Every legal move is tried, so the score is exact. The search only
+// stops early where Benson's algorithm settles enough of the board to
+// decide it, and grows quickly with the area, mostly through the long
+// lines that superko allows: 3x3 and 2x5 are solved in seconds, but 3x4
+// doesn't finish in 12 minutes, and boards from 4x4 to 5x5, such as the
+// 5x4 board of fast.go, are out of reach. Long searches can be stopped
+// with a budget and resumed from the table they saved.
+package exact
+
+import "engine"
+import "errors"
+import "fmt"
+import "time"
+
+// A move on the board, at Y, X unless it's a pass.
+type Move struct {
+  Y, X int
+  Pass bool
+}
+
+type Result struct {
+  // Black area minus white area.
+  Score int
+  // The principal variation, as far as the table knows it.
+  Moves []Move
+}
+
+// Results kept for each position, found on lines that repeat different
+// positions.
+const BUCKET_SIZE = 4
+
+const (
+  exact_bound = iota
+  lower_bound
+  upper_bound
+)
+
+type entry struct {
+  score int8
+  bound int8
+  // Best move in the canonical orientation, with the area for pass, or
+  // -1 if unknown.
+  best int8
+  // Symmetry that made the position canonical when it was searched, which
+  // is the orientation of repeated.
+  symmetry int8
+  // Positions above this one that superko kept the search from repeating.
+  repeated []uint64
+  // Canonical positions met below this one.
+  visited positionSet
+}
+
+// A set of canonical positions that may answer yes for positions that
+// are not there, but never no for positions that are.
+type positionSet [8]uint64
+
+// The word and the bit of a position in a positionSet.
+func positionBit(code uint64) (int, uint64) {
+  hash := code * 0x9e3779b97f4a7c15 >> 55
+  return int(hash >> 6), 1 << (hash & 63)
+}
+
+func (set *positionSet) add(code uint64) {
+  word, mask := positionBit(code)
+  set[word] |= mask
+}
+
+func (set *positionSet) merge(other *positionSet) {
+  for i := range set {
+    set[i] |= other[i]
+  }
+}
+
+// A position of the current line, as played and in canonical form, with
+// its depth and the bit of the canonical form in a positionSet.
+type step struct {
+  code, canonical uint64
+  depth, word int
+  mask uint64
+}
+
+var ErrBudget = errors.New("search budget exceeded")
+
+type Solver struct {
+  // Search limits of each call to Solve, ignored when zero. The table
+  // keeps the results found so far when a limit is hit.
+  MaxNodes int
+  Timeout time.Duration
+  // Called every Interval while searching, so the results found so far
+  // can be saved before the search ends.
+  Checkpoint func()
+  Interval time.Duration
+
+  rows, cols, area int
+  start *board
+  // Bounds of the score found by the searches so far.
+  low, high int
+  table map[uint64][]entry
+  // Positions of the current line and their depth, for superko.
+  path map[uint64]int
+  line []step
+  // Points ordered from the center out, for move ordering.
+  order []int
+  // Moves of each depth of the line.
+  buffers [][]int
+  // Positions searched.
+  Nodes int
+  limit int
+  deadline time.Time
+  checkpoint time.Time
+  stopped bool
+}
+
+func NewSolver(rows, cols int) (*Solver, error) {
+  if rows < 1 || cols < 1 || rows * cols > MAX_AREA {
+    return nil, fmt.Errorf("exact: board %dx%d is too large", rows, cols)
+  }
+  s := &Solver{rows: rows, cols: cols, area: rows * cols}
+  s.start = newBoard(rows, cols)
+  s.low, s.high = -s.area, s.area
+  s.table = make(map[uint64][]entry)
+  s.path = make(map[uint64]int)
+  for p := 0; p < s.area; p++ {
+    s.order = append(s.order, p)
+  }
+  distance := func (p int) int {
+    dy, dx := 2 * (p / cols) - (rows - 1), 2 * (p % cols) - (cols - 1)
+    return dy * dy + dx * dx
+  }
+  for i := 1; i < len(s.order); i++ {
+    for j := i; j > 0 && distance(s.order[j]) < distance(s.order[j - 1]);
+        j-- {
+      s.order[j], s.order[j - 1] = s.order[j - 1], s.order[j]
+    }
+  }
+  return s, nil
+}
+
+// Key of the position in the table, and the symmetry that makes it
+// canonical.
+func (s *Solver) key(b *board, to_play engine.Color, passes, ko int) (
+    uint64, int) {
+  code, symmetry := b.canonical()
+  side := uint64(0)
+  if to_play == engine.WHITE {
+    side = 1
+  }
+  if ko >= 0 {
+    ko = b.symmetries[symmetry][ko]
+  }
+  key := (code * 2 + side) * 2 + uint64(passes)
+  return key * uint64(s.area + 1) + uint64(ko + 1), symmetry
+}
+
+// Convert a point between the board and the canonical orientation.
+func (s *Solver) toCanonical(b *board, symmetry, p int) int {
+  if p == s.area {
+    return p
+  }
+  return b.symmetries[symmetry][p]
+}
+
+func (s *Solver) fromCanonical(b *board, symmetry, p int) int {
+  if p == s.area {
+    return p
+  }
+  return b.inverses[symmetry][p]
+}
+
+// Solve the empty board with black to play. The score is found by
+// bisection with null window searches, which cut far more than a single
+// search with the full window, and share the table between them. If a
+// limit is hit, returns ErrBudget, and calling Solve again goes on from
+// the bounds and the table found so far. Each call starts again from the
+// root, and results that depend on the line can be lost, so a budget far
+// smaller than the search left may not make progress.
+func (s *Solver) Solve() (Result, error) {
+  s.stopped = false
+  s.limit, s.deadline = 0, time.Time{}
+  if s.MaxNodes > 0 {
+    s.limit = s.Nodes + s.MaxNodes
+  }
+  if s.Timeout > 0 {
+    s.deadline = time.Now().Add(s.Timeout)
+  }
+  s.checkpoint = time.Now().Add(s.Interval)
+  s.push(s.start, 0)
+  defer s.pop()
+  for s.low < s.high {
+    guess := (s.low + s.high + 1) / 2
+    score, _, _ := s.search(s.start, engine.BLACK, 0, -1, 0, guess - 1, guess)
+    if s.stopped {
+      return Result{}, ErrBudget
+    }
+    if score >= guess {
+      s.low = guess
+    } else {
+      s.high = guess - 1
+    }
+  }
+  return Result{s.low, s.principalVariation()}, nil
+}
+
+// Check the limits of the search, and call the checkpoint when due.
+func (s *Solver) exhausted() bool {
+  if s.limit > 0 && s.Nodes >= s.limit {
+    s.stopped = true
+  }
+  if s.Nodes % 1024 != 0 {
+    return s.stopped
+  }
+  now := time.Now()
+  if !s.deadline.IsZero() && now.After(s.deadline) {
+    s.stopped = true
+  }
+  if s.Checkpoint != nil && s.Interval > 0 && now.After(s.checkpoint) {
+    s.Checkpoint()
+    s.checkpoint = time.Now().Add(s.Interval)
+  }
+  return s.stopped
+}
+
+func (s *Solver) push(b *board, depth int) {
+  code := b.code()
+  canonical, _ := b.canonical()
+  s.path[code] = depth
+  word, mask := positionBit(canonical)
+  s.line = append(s.line, step{code, canonical, depth, word, mask})
+}
+
+func (s *Solver) pop() {
+  delete(s.path, s.line[len(s.line) - 1].code)
+  s.line = s.line[:len(s.line) - 1]
+}
+
+// Negamax alpha-beta search, returning the score for the player to move,
+// who can't play at ko. Taking back a ko is part of the key, while longer
+// repetitions are found by looking at the line, which holds the position
+// at the given depth and the ones above it. Besides the score, returns
+// the positions above this one that superko kept the search from
+// repeating, and the positions met below this one.
+//
+// Stored results keep both, and they are only used again when the
+// positions of the line that the search could meet are the same ones
+// that it repeated before. Without this, a result found when superko
+// forbids some moves would be trusted on lines where it doesn't, and
+// the other way around.
+//
+// When a limit stops the search, nothing more is stored and the score
+// returned is meaningless.
+func (s *Solver) search(b *board, to_play engine.Color, passes, ko int,
+                        depth, alpha, beta int) (
+    int, []uint64, positionSet) {
+  s.Nodes++
+  var repeated []uint64
+  var visited positionSet
+  sign := 1
+  if to_play == engine.WHITE {
+    sign = -1
+  }
+  if passes == 2 {
+    return sign * b.score(), repeated, visited
+  }
+  if s.exhausted() {
+    return 0, repeated, visited
+  }
+  // Settled points don't change hands, whatever the line. A pass just
+  // before could end the game before the stones inside them are
+  // captured, so only the positions after a move are cut.
+  if passes == 0 {
+    low, high := b.bounds()
+    if sign < 0 {
+      low, high = -high, -low
+    }
+    switch {
+    case low == high:
+      return low, repeated, visited
+    case high <= alpha:
+      return high, repeated, visited
+    case low >= beta:
+      return low, repeated, visited
+    }
+  }
+  key, symmetry := s.key(b, to_play, passes, ko)
+  first, slot := -1, -1
+  bucket := s.table[key]
+  for i := range bucket {
+    if line_repeated, ok := s.reusable(&bucket[i], b, symmetry, depth); ok {
+      slot, repeated = i, line_repeated
+      break
+    }
+  }
+  if len(bucket) > 0 {
+    // The newest result is the best guess of the move to try first when
+    // none holds for this line.
+    e := bucket[len(bucket) - 1]
+    if slot >= 0 {
+      e = bucket[slot]
+      visited = e.visited
+      switch {
+      case e.bound == exact_bound:
+        return int(e.score), repeated, visited
+      case e.bound == lower_bound && int(e.score) > alpha:
+        alpha = int(e.score)
+      case e.bound == upper_bound && int(e.score) < beta:
+        beta = int(e.score)
+      }
+      if alpha >= beta {
+        return int(e.score), repeated, visited
+      }
+    }
+    if e.best >= 0 {
+      first = s.fromCanonical(b, symmetry, int(e.best))
+    }
+  }
+  original_alpha := alpha
+  best, best_move := -s.area - 1, -1
+  for _, move := range s.moves(b, first, passes, depth) {
+    child := b
+    child_passes, child_ko := passes + 1, -1
+    if move != s.area {
+      if move == ko {
+        continue
+      }
+      child = b.copy()
+      var ok bool
+      if child_ko, ok = child.play(move, to_play); !ok {
+        continue
+      }
+      code := child.code()
+      canonical, _ := child.canonical()
+      visited.add(canonical)
+      if _, ok := s.path[code]; ok {
+        repeated = addPosition(repeated, code)
+        continue
+      }
+      child_passes = 0
+      s.push(child, depth + 1)
+    }
+    value, child_repeated, child_visited := s.search(
+        child, engine.Opposite(to_play), child_passes, child_ko, depth + 1,
+        -beta, -alpha)
+    if move != s.area {
+      s.pop()
+    }
+    if s.stopped {
+      return 0, nil, visited
+    }
+    for _, code := range child_repeated {
+      repeated = addPosition(repeated, code)
+    }
+    visited.merge(&child_visited)
+    value = -value
+    if value > best {
+      best, best_move = value, move
+    }
+    if value > alpha {
+      alpha = value
+    }
+    if alpha >= beta {
+      break
+    }
+  }
+  // Repeating this position or the ones below it doesn't depend on how
+  // the line got here.
+  var above []uint64
+  for _, code := range repeated {
+    if line, ok := s.path[code]; ok && line < depth {
+      above = append(above, code)
+    }
+  }
+  e := entry{int8(best), exact_bound,
+             int8(s.toCanonical(b, symmetry, best_move)), int8(symmetry),
+             above, visited}
+  switch {
+  case best <= original_alpha:
+    e.bound = upper_bound
+  case best >= beta:
+    e.bound = lower_bound
+  }
+  s.store(key, slot, e)
+  return best, above, visited
+}
+
+// Store a result, replacing the one in slot that held for this line, if
+// any, or else the oldest one when the bucket is full.
+func (s *Solver) store(key uint64, slot int, e entry) {
+  bucket := s.table[key]
+  switch {
+  case slot >= 0 && slot < len(bucket):
+    bucket[slot] = e
+  case len(bucket) < BUCKET_SIZE:
+    s.table[key] = append(bucket, e)
+  default:
+    copy(bucket, bucket[1:])
+    bucket[len(bucket) - 1] = e
+  }
+}
+
+// Check if a stored result holds for the current line, returning the
+// positions of the line it repeated.
+func (s *Solver) reusable(e *entry, b *board, symmetry, depth int) (
+    []uint64, bool) {
+  var repeated []uint64
+  for _, step := range s.line {
+    if step.depth >= depth {
+      break
+    }
+    if e.visited[step.word] & step.mask == 0 {
+      continue
+    }
+    code := b.transform(step.code, symmetry, int(e.symmetry))
+    found := false
+    for _, old := range e.repeated {
+      found = found || old == code
+    }
+    if !found {
+      return nil, false
+    }
+    repeated = append(repeated, step.code)
+  }
+  return repeated, len(repeated) == len(e.repeated)
+}
+
+func addPosition(positions []uint64, code uint64) []uint64 {
+  for _, old := range positions {
+    if old == code {
+      return positions
+    }
+  }
+  return append(positions, code)
+}
+
+// Moves to try: the best one from the table, then the empty points from
+// the center out, and pass. After a pass, passing back is tried first,
+// since it ends the game.
+func (s *Solver) moves(b *board, first, passes, depth int) []int {
+  for len(s.buffers) <= depth {
+    s.buffers = append(s.buffers, make([]int, 0, s.area + 1))
+  }
+  moves := s.buffers[depth][:0]
+  if passes > 0 && first != s.area {
+    moves = append(moves, s.area)
+  }
+  if first >= 0 {
+    moves = append(moves, first)
+  }
+  empty := b.empty()
+  for _, p := range s.order {
+    if p == first || empty & (1 << uint(p)) == 0 {
+      continue
+    }
+    moves = append(moves, p)
+  }
+  if passes == 0 && first != s.area {
+    moves = append(moves, s.area)
+  }
+  s.buffers[depth] = moves
+  return moves
+}
+
+// Follow the best moves of the table from the empty board.
+func (s *Solver) principalVariation() []Move {
+  moves := []Move{}
+  b := s.start
+  to_play := engine.Color(engine.BLACK)
+  passes, ko := 0, -1
+  seen := map[uint64]bool{b.code(): true}
+  for passes < 2 && len(moves) < 3 * s.area {
+    key, symmetry := s.key(b, to_play, passes, ko)
+    bucket := s.table[key]
+    if len(bucket) == 0 || bucket[len(bucket) - 1].best < 0 {
+      break
+    }
+    e := bucket[len(bucket) - 1]
+    move := s.fromCanonical(b, symmetry, int(e.best))
+    if move == s.area {
+      moves = append(moves, Move{Pass: true})
+      passes, ko = passes + 1, -1
+    } else {
+      next := b.copy()
+      var ok bool
+      if ko, ok = next.play(move, to_play); !ok || seen[next.code()] {
+        break
+      }
+      b = next
+      seen[b.code()] = true
+      moves = append(moves, Move{Y: move / s.cols, X: move % s.cols})
+      passes = 0
+    }
+    to_play = engine.Opposite(to_play)
+  }
+  return moves
+}
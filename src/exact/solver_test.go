@@ -0,0 +1,140 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package exact
+
+import "engine"
+import "io/ioutil"
+import "os"
+import "testing"
+import "time"
+
+func TestSolve(t *testing.T) {
+  // Known values of perfect play with area scoring.
+  boards := []struct {
+    rows, cols, score int
+  }{
+    {1, 1, 0},
+    {1, 2, 0},
+    {1, 3, 3},
+    {1, 4, 4},
+    {1, 5, 0},
+    {2, 2, 1},
+    {2, 4, 8},
+    {3, 3, 9},
+  }
+  for _, board := range boards {
+    if testing.Short() && board.rows * board.cols > 8 {
+      continue
+    }
+    solver, err := NewSolver(board.rows, board.cols)
+    if err != nil {
+      t.Fatal(err)
+    }
+    result, err := solver.Solve()
+    if err != nil {
+      t.Fatal(err)
+    }
+    if result.Score != board.score {
+      t.Errorf("Board %dx%d, expected %d, actual %d", board.rows,
+               board.cols, board.score, result.Score)
+    }
+    if len(result.Moves) == 0 {
+      t.Errorf("Board %dx%d has no principal variation", board.rows,
+               board.cols)
+    }
+  }
+}
+
+func TestNewSolverTooLarge(t *testing.T) {
+  if _, err := NewSolver(5, 7); err == nil {
+    t.Errorf("Expected an error for a 5x7 board")
+  }
+}
+
+func TestBudget(t *testing.T) {
+  solver, _ := NewSolver(2, 3)
+  solver.MaxNodes = 1000
+  runs := 0
+  result, err := solver.Solve()
+  for ; err == ErrBudget && runs < 100; runs++ {
+    result, err = solver.Solve()
+  }
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected, _ := NewSolver(2, 3)
+  if full, _ := expected.Solve(); result.Score != full.Score || runs == 0 {
+    t.Errorf("Expected score %d after stopping, actual %d in %d runs",
+             full.Score, result.Score, runs + 1)
+  }
+}
+
+func TestCheckpoint(t *testing.T) {
+  solver, _ := NewSolver(2, 4)
+  calls := 0
+  solver.Checkpoint = func() {
+    calls++
+  }
+  solver.Interval = time.Nanosecond
+  if _, err := solver.Solve(); err != nil || calls == 0 {
+    t.Errorf("Expected checkpoints while searching, got %d calls, %v",
+             calls, err)
+  }
+}
+
+func TestSymmetricKeys(t *testing.T) {
+  solver, _ := NewSolver(3, 3)
+  corner, opposite := newBoard(3, 3), newBoard(3, 3)
+  corner.play(0, engine.BLACK)
+  opposite.play(8, engine.BLACK)
+  key1, symmetry1 := solver.key(corner, engine.WHITE, 0, -1)
+  key2, symmetry2 := solver.key(opposite, engine.WHITE, 0, -1)
+  if key1 != key2 {
+    t.Errorf("Expected the same key for symmetric corners")
+  }
+  if solver.toCanonical(corner, symmetry1, 0) !=
+     solver.toCanonical(opposite, symmetry2, 8) {
+    t.Errorf("Expected the stones to have the same canonical point")
+  }
+}
+
+func TestCache(t *testing.T) {
+  dir, err := ioutil.TempDir("", "exact")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+  solver, _ := NewSolver(2, 2)
+  expected, _ := solver.Solve()
+  name := solver.CacheFile(dir)
+  if err := solver.Save(name); err != nil {
+    t.Fatal(err)
+  }
+  cached, _ := NewSolver(2, 2)
+  if err := cached.Load(name); err != nil {
+    t.Fatal(err)
+  }
+  result, _ := cached.Solve()
+  if result.Score != expected.Score || cached.Nodes >= solver.Nodes {
+    t.Errorf("Expected the cached score %d in less than %d nodes, " +
+             "actual %d in %d", expected.Score, solver.Nodes, result.Score,
+             cached.Nodes)
+  }
+  if err := cached.Load(solver.CacheFile(dir + "/missing")); err != nil {
+    t.Errorf("Expected a missing cache to be empty, got %v", err)
+  }
+}
@@ -17,45 +17,81 @@
 package gtp
 
 import "engine"
+import "sgf"
 import "io"
 import "bufio"
 import "strings"
 import "bytes"
 import "fmt"
 import "strconv"
+import "errors"
+import "os"
+import "path/filepath"
+import "time"
 
 type Driver interface {
   Run(reader io.Reader, writer io.Writer)
 }
 
 type Session struct {
+  // If set, every game is saved as SGF in this directory.
+  SaveDirectory string
+  games int
 }
 
 // TODO(ricbit): Remove this global var.
 var state *engine.GameState
 
-type Handler func ([]string) string
+type Handler func ([]string) (string, error)
 
 var commands = map[string] Handler {
   "name" : Name,
   "protocol_version" : ProtocolVersion,
   "version" : Version,
   "boardsize" : BoardSize,
+  "rectangular_boardsize" : RectangularBoardSize,
   "clear_board" : ClearBoard,
   "play" : Play,
   "genmove" : GenMove,
   "komi" : Komi,
+  "kgs-rules" : KgsRules,
+  "fixed_handicap" : FixedHandicap,
+  "place_free_handicap" : PlaceFreeHandicap,
+  "set_free_handicap" : SetFreeHandicap,
+  "ricbot-komi_mode" : KomiMode,
+  "loadsgf" : LoadSgf,
+  "printsgf" : PrintSgf,
+  "showboard" : ShowBoard,
+  "final_score" : FinalScore,
+  "final_status_list" : FinalStatusList,
+  "gogui-analyze_commands" : AnalyzeCommands,
+  "ricbot-set_region" : SetRegion,
+  "ricbot-set_playout_region" : SetPlayoutRegion,
+  "ricbot-clear_region" : ClearRegion,
+  "ricbot-region" : Region,
+}
+
+// Commands that discard the current game.
+var new_game = map[string] bool {
+  "boardsize" : true,
+  "rectangular_boardsize" : true,
+  "clear_board" : true,
+  "loadsgf" : true,
 }
 
 func (s *Session) Run(reader io.Reader, writer io.Writer) {
   buf := bufio.NewReader(reader)
   state = new(engine.GameState)
+  defer s.saveGame()
   for {
     line, _, ok := buf.ReadLine()
     if ok != nil {
       return
     }
     args := strings.Split(bytes.NewBuffer(line).String(), " ")
+    if new_game[args[0]] {
+      s.saveGame()
+    }
     switch args[0] {
       case "quit":
         return
@@ -63,7 +99,11 @@ func (s *Session) Run(reader io.Reader, writer io.Writer) {
         fmt.Fprint(writer, "= " + ListCommands() + "\n\n")
       default:
         if handler, ok := commands[args[0]]; ok {
-          fmt.Fprint(writer, "= " + handler(args[1:]) + "\n\n")
+          if response, err := handler(args[1:]); err != nil {
+            fmt.Fprint(writer, "? " + err.Error() + "\n\n")
+          } else {
+            fmt.Fprint(writer, "= " + response + "\n\n")
+          }
         } else {
           fmt.Fprint(writer, "?\n\n")
         }
@@ -71,16 +111,33 @@ func (s *Session) Run(reader io.Reader, writer io.Writer) {
   }
 }
 
-func Name(args []string) string {
-  return "ricbot"
+func (s *Session) saveGame() {
+  if s.SaveDirectory == "" || state.GetGoban() == nil ||
+     len(state.GetRecord()) == 0 {
+    return
+  }
+  s.games++
+  name := fmt.Sprintf("ricbot-%s-%d.sgf",
+                      time.Now().Format("20060102-150405"), s.games)
+  file, err := os.Create(filepath.Join(s.SaveDirectory, name))
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "# cannot save game: %v\n", err)
+    return
+  }
+  defer file.Close()
+  sgf.Write(file, state)
+}
+
+func Name(args []string) (string, error) {
+  return "ricbot", nil
 }
 
-func ProtocolVersion(args []string) string {
-  return "2"
+func ProtocolVersion(args []string) (string, error) {
+  return "2", nil
 }
 
-func Version(args []string) string {
-  return "1.0"
+func Version(args []string) (string, error) {
+  return "1.0", nil
 }
 
 func ListCommands() string {
@@ -91,59 +148,402 @@ func ListCommands() string {
   return strings.Join(output, "\n")
 }
 
-func BoardSize(args []string) string {
-  size, _ := strconv.Atoi(args[0])
-  state.BoardSize(size)
-  return ""
+func parseSize(arg string) (int, error) {
+  size, err := strconv.Atoi(arg)
+  if err != nil {
+    return 0, errors.New("syntax error")
+  }
+  if size < 1 || size > MAX_BOARD_SIZE {
+    return 0, errors.New("unacceptable size")
+  }
+  return size, nil
 }
 
-func ClearBoard(args []string) string {
-  state.ClearBoard()
-  return ""
+func BoardSize(args []string) (string, error) {
+  if len(args) < 1 {
+    return "", errors.New("syntax error")
+  }
+  size, err := parseSize(args[0])
+  if err != nil {
+    return "", err
+  }
+  state.BoardSize(size)
+  return "", nil
 }
 
-func stringToColor(s string) engine.Color {
-  switch strings.ToLower(s)[0] {
-  case 'b':
-    return engine.BLACK
-  case 'w':
-    return engine.WHITE
+// Extension to play on non-square boards. The arguments are the number
+// of columns and rows, in this order.
+func RectangularBoardSize(args []string) (string, error) {
+  if len(args) < 2 {
+    return "", errors.New("syntax error")
+  }
+  size_x, err := parseSize(args[0])
+  if err != nil {
+    return "", err
   }
-  return engine.EMPTY
+  size_y, err := parseSize(args[1])
+  if err != nil {
+    return "", err
+  }
+  state.RectangularBoardSize(size_y, size_x)
+  return "", nil
 }
 
-func stringToPosition(s string) (y, x int) {
-  x = int(strings.ToLower(s)[0]) - int('a')
-  temp, _ := strconv.Atoi(s[1:])
-  y = temp - 1
-  return y, x
+func ClearBoard(args []string) (string, error) {
+  state.ClearBoard()
+  return "", nil
 }
 
-func Play(args []string) string {
-  color := stringToColor(args[0])
-  if strings.ToLower(args[1]) == "pass" {
-    return ""
+func stringToColor(s string) (engine.Color, error) {
+  switch strings.ToLower(s) {
+  case "b", "black":
+    return engine.BLACK, nil
+  case "w", "white":
+    return engine.WHITE, nil
+  }
+  return engine.EMPTY, errors.New("invalid color")
+}
+
+func Play(args []string) (string, error) {
+  if len(args) < 2 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  color, err := stringToColor(args[0])
+  if err != nil {
+    return "", err
+  }
+  goban := state.GetGoban()
+  vertex, err := ParseVertex(args[1], goban.SizeY(), goban.SizeX())
+  if err != nil {
+    return "", err
   }
-  y, x := stringToPosition(args[1])
-  state.Play(y, x, color)
-  return ""
+  if vertex.Pass {
+    state.Pass(color)
+    return "", nil
+  }
+  if !state.Play(vertex.Y, vertex.X, color) {
+    return "", errors.New("illegal move")
+  }
+  return "", nil
 }
 
-func GenMove(args []string) string {
-  color := stringToColor(args[0])
+func GenMove(args []string) (string, error) {
+  if len(args) < 1 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  color, err := stringToColor(args[0])
+  if err != nil {
+    return "", err
+  }
   y, x, pass := state.GenMove(color)
   if pass {
-    return "pass"
+    state.Pass(color)
+    return Vertex{Pass: true}.String(), nil
   }
-  state.Play(y, x, color)
-  return fmt.Sprintf("%s%d", string(int('a') + x), y + 1)
+  if !state.Play(y, x, color) {
+    return "", errors.New("illegal move")
+  }
+  return Vertex{Y: y, X: x}.String(), nil
 }
 
-func Komi(args []string) string {
+func Komi(args []string) (string, error) {
   komi, _ := strconv.ParseFloat(args[0], 32)
   state.Komi(float32(komi))
-  return ""
+  return "", nil
+}
+
+func KgsRules(args []string) (string, error) {
+  if len(args) < 1 {
+    return "", errors.New("syntax error")
+  }
+  rules, ok := engine.RulesetByName(strings.ToLower(args[0]))
+  if !ok {
+    return "", errors.New("unknown ruleset")
+  }
+  state.Rules(rules)
+  return "", nil
+}
+
+func emptyBoard(goban engine.Goban) bool {
+  for y := 0; y < goban.SizeY(); y++ {
+    for x := 0; x < goban.SizeX(); x++ {
+      if goban.GetColor(y, x) != engine.EMPTY {
+        return false
+      }
+    }
+  }
+  return true
+}
+
+// Place the handicap stones and list them, one vertex per line.
+func placeHandicap(stones int) (string, error) {
+  goban := state.GetGoban()
+  if !emptyBoard(goban) {
+    return "", errors.New("board not empty")
+  }
+  if !state.FixedHandicap(stones) {
+    return "", errors.New("invalid number of stones")
+  }
+  setup := state.GetSetup()
+  output := []string{}
+  for _, stone := range setup[len(setup) - stones:] {
+    output = append(output, Vertex{Y: stone.Y, X: stone.X}.String())
+  }
+  return strings.Join(output, " "), nil
+}
+
+func FixedHandicap(args []string) (string, error) {
+  if len(args) < 1 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  stones, err := strconv.Atoi(args[0])
+  if err != nil {
+    return "", errors.New("syntax error")
+  }
+  return placeHandicap(stones)
+}
+
+// Free handicap stones are placed at the fixed handicap points, so fewer
+// stones than requested may be placed on large handicaps.
+func PlaceFreeHandicap(args []string) (string, error) {
+  if len(args) < 1 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  stones, err := strconv.Atoi(args[0])
+  if err != nil {
+    return "", errors.New("syntax error")
+  }
+  goban := state.GetGoban()
+  limit := engine.MaxFixedHandicap(goban.SizeY(), goban.SizeX())
+  if limit >= 2 && stones > limit {
+    stones = limit
+  }
+  return placeHandicap(stones)
+}
+
+func SetFreeHandicap(args []string) (string, error) {
+  goban := state.GetGoban()
+  if goban == nil {
+    return "", errors.New("syntax error")
+  }
+  if !emptyBoard(goban) {
+    return "", errors.New("board not empty")
+  }
+  vertices := []Vertex{}
+  seen := map[Vertex] bool{}
+  for _, arg := range args {
+    if arg == "" {
+      continue
+    }
+    vertex, err := ParseVertex(arg, goban.SizeY(), goban.SizeX())
+    if err != nil || vertex.Pass || seen[vertex] {
+      return "", errors.New("bad vertex list")
+    }
+    seen[vertex] = true
+    vertices = append(vertices, vertex)
+  }
+  if len(vertices) < 2 || len(vertices) >= goban.SizeY() * goban.SizeX() {
+    return "", errors.New("bad vertex list")
+  }
+  for _, vertex := range vertices {
+    state.Setup(vertex.Y, vertex.X, engine.BLACK)
+  }
+  state.Handicap(len(vertices))
+  return "", nil
+}
+
+var komi_modes = map[string] engine.KomiMode {
+  "none" : engine.NO_DYNAMIC_KOMI,
+  "situational" : engine.SITUATIONAL_KOMI,
+  "value" : engine.VALUE_KOMI,
 }
 
+// Extension to select the dynamic komi used by the search.
+func KomiMode(args []string) (string, error) {
+  if len(args) < 1 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  mode, ok := komi_modes[strings.ToLower(args[0])]
+  if !ok {
+    return "", errors.New("unknown komi mode")
+  }
+  state.DynamicKomi(mode)
+  return "", nil
+}
 
+// Load the main line of an SGF file, optionally stopping before the
+// given move number.
+func LoadSgf(args []string) (string, error) {
+  if len(args) < 1 {
+    return "", errors.New("syntax error")
+  }
+  move_number := 0
+  if len(args) > 1 {
+    number, err := strconv.Atoi(args[1])
+    if err != nil {
+      return "", errors.New("syntax error")
+    }
+    move_number = number
+  }
+  file, err := os.Open(args[0])
+  if err != nil {
+    return "", errors.New("cannot load file")
+  }
+  defer file.Close()
+  games, err := sgf.Read(file)
+  if err != nil {
+    return "", errors.New("cannot load file")
+  }
+  loaded, err := sgf.ToGameState(games[0], move_number)
+  if err != nil {
+    return "", errors.New("cannot load file")
+  }
+  state = loaded
+  return "", nil
+}
+
+// Print the game as SGF, or save it if a filename is given.
+func PrintSgf(args []string) (string, error) {
+  if state.GetGoban() == nil {
+    return "", errors.New("no board")
+  }
+  if len(args) == 0 || args[0] == "" {
+    return strings.TrimRight(sgf.Format(state), "\n"), nil
+  }
+  file, err := os.Create(args[0])
+  if err != nil {
+    return "", errors.New("cannot save file")
+  }
+  defer file.Close()
+  if err := sgf.Write(file, state); err != nil {
+    return "", errors.New("cannot save file")
+  }
+  return "", nil
+}
+
+func ShowBoard(args []string) (string, error) {
+  if state.GetGoban() == nil {
+    return "", errors.New("no board")
+  }
+  return "\n" + strings.TrimRight(engine.RenderBoard(state), "\n"), nil
+}
+
+func FinalScore(args []string) (string, error) {
+  if state.GetGoban() == nil {
+    return "", errors.New("no board")
+  }
+  score := state.FinalScore()
+  format := func (f float32) string {
+    return strconv.FormatFloat(float64(f), 'f', -1, 32)
+  }
+  switch {
+  case score > 0:
+    return "B+" + format(score), nil
+  case score < 0:
+    return "W+" + format(-score), nil
+  }
+  return "0", nil
+}
+
+var stone_status = map[string] engine.StoneStatus {
+  "alive" : engine.ALIVE,
+  "dead" : engine.DEAD,
+  "seki" : engine.SEKI,
+}
 
+// List the stones with the given status, one vertex per line.
+func FinalStatusList(args []string) (string, error) {
+  if len(args) < 1 || state.GetGoban() == nil {
+    return "", errors.New("syntax error")
+  }
+  wanted, ok := stone_status[strings.ToLower(args[0])]
+  if !ok {
+    return "", errors.New("syntax error")
+  }
+  goban := state.GetGoban()
+  status := state.FinalStatus()
+  output := []string{}
+  for y := goban.SizeY() - 1; y >= 0; y-- {
+    for x := 0; x < goban.SizeX(); x++ {
+      color := goban.GetColor(y, x)
+      if (color == engine.BLACK || color == engine.WHITE) &&
+         status[y * goban.SizeX() + x] == wanted {
+        output = append(output, Vertex{Y: y, X: x}.String())
+      }
+    }
+  }
+  return strings.Join(output, "\n"), nil
+}
+
+// Commands shown in the GoGui analyze menu. The regions are set from the
+// points selected on the board.
+var analyze_commands = []string{
+  "none/Set Region/ricbot-set_region %P",
+  "none/Set Region With Playouts/ricbot-set_playout_region %P",
+  "none/Clear Region/ricbot-clear_region",
+  "plist/Show Region/ricbot-region",
+}
+
+func AnalyzeCommands(args []string) (string, error) {
+  return strings.Join(analyze_commands, "\n"), nil
+}
+
+// Restrict the search to the given points. Without points, the region is
+// removed.
+func setRegion(args []string, playouts bool) (string, error) {
+  goban := state.GetGoban()
+  if goban == nil {
+    return "", errors.New("no board")
+  }
+  region := make([]bool, goban.SizeY() * goban.SizeX())
+  points := 0
+  for _, arg := range args {
+    if arg == "" {
+      continue
+    }
+    vertex, err := ParseVertex(arg, goban.SizeY(), goban.SizeX())
+    if err != nil || vertex.Pass {
+      return "", errors.New("invalid coordinate")
+    }
+    region[vertex.Y * goban.SizeX() + vertex.X] = true
+    points++
+  }
+  if points == 0 {
+    region = nil
+  }
+  if !state.SetRegion(region, playouts) {
+    return "", errors.New("invalid region")
+  }
+  return "", nil
+}
+
+func SetRegion(args []string) (string, error) {
+  return setRegion(args, false)
+}
+
+func SetPlayoutRegion(args []string) (string, error) {
+  return setRegion(args, true)
+}
+
+func ClearRegion(args []string) (string, error) {
+  state.SetRegion(nil, false)
+  return "", nil
+}
+
+// List the points of the region.
+func Region(args []string) (string, error) {
+  goban := state.GetGoban()
+  region, _ := state.GetRegion()
+  if goban == nil || region == nil {
+    return "", nil
+  }
+  output := []string{}
+  for y := 0; y < goban.SizeY(); y++ {
+    for x := 0; x < goban.SizeX(); x++ {
+      if region[y * goban.SizeX() + x] {
+        output = append(output, Vertex{Y: y, X: x}.String())
+      }
+    }
+  }
+  return strings.Join(output, " "), nil
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package gtp
+
+import "testing"
+import "engine"
+import "bytes"
+import "strings"
+
+// Run the commands in a new session and return the responses.
+func runSession(commands ...string) []string {
+  var output bytes.Buffer
+  session := new(Session)
+  session.Run(strings.NewReader(strings.Join(commands, "\n") + "\n"),
+              &output)
+  return strings.Split(strings.TrimRight(output.String(), "\n"), "\n\n")
+}
+
+func TestKgsRules(t *testing.T) {
+  responses := runSession("boardsize 9", "kgs-rules", "kgs-rules foo",
+                          "kgs-rules japanese")
+  expected := []string{"= ", "? syntax error", "? unknown ruleset", "= "}
+  if strings.Join(responses, "|") != strings.Join(expected, "|") {
+    t.Errorf("Expected %q, actual %q", expected, responses)
+  }
+}
+
+func TestFixedHandicap(t *testing.T) {
+  responses := runSession("boardsize 9", "fixed_handicap 10",
+                          "fixed_handicap 5", "fixed_handicap 2",
+                          "clear_board", "fixed_handicap x")
+  expected := []string{"= ", "? invalid number of stones",
+                       "= C3 G7 C7 G3 E5", "? board not empty", "= ",
+                       "? syntax error"}
+  if strings.Join(responses, "|") != strings.Join(expected, "|") {
+    t.Errorf("Expected %q, actual %q", expected, responses)
+  }
+  responses = runSession("boardsize 13", "fixed_handicap 2", "printsgf")
+  if responses[1] != "= D4 K10" ||
+     !strings.Contains(responses[2], "HA[2]") ||
+     !strings.Contains(responses[2], "AB[dj][jd]") {
+    t.Errorf("Wrong handicap %q", responses)
+  }
+}
+
+func TestFreeHandicap(t *testing.T) {
+  responses := runSession("boardsize 8", "place_free_handicap 9",
+                          "clear_board", "set_free_handicap A1",
+                          "set_free_handicap A1 A1",
+                          "set_free_handicap A1 B2 C3")
+  expected := []string{"= ", "= C3 F6 C6 F3", "= ", "? bad vertex list",
+                       "? bad vertex list", "= "}
+  if strings.Join(responses, "|") != strings.Join(expected, "|") {
+    t.Errorf("Expected %q, actual %q", expected, responses)
+  }
+  if state.GetHandicap() != 3 ||
+     state.GetGoban().GetColor(1, 1) != engine.BLACK {
+    t.Errorf("Wrong handicap %d", state.GetHandicap())
+  }
+}
+
+func TestKomiMode(t *testing.T) {
+  responses := runSession("boardsize 9", "ricbot-komi_mode foo",
+                          "ricbot-komi_mode situational")
+  expected := []string{"= ", "? unknown komi mode", "= "}
+  if strings.Join(responses, "|") != strings.Join(expected, "|") {
+    t.Errorf("Expected %q, actual %q", expected, responses)
+  }
+  if state.GetKomiMode() != engine.SITUATIONAL_KOMI {
+    t.Errorf("Expected situational komi, actual %d", state.GetKomiMode())
+  }
+}
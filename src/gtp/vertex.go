@@ -0,0 +1,65 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package gtp
+
+import "engine"
+import "errors"
+import "fmt"
+import "strconv"
+import "strings"
+
+// Columns are named with letters from A to Z skipping I, and then with
+// two letters (AA, AB, ...) on boards wider than 25 points. Boards are
+// limited to the largest size that can be saved as SGF.
+const MAX_BOARD_SIZE = 52
+
+// A point on the board in engine coordinates, or a pass.
+type Vertex struct {
+  Y, X int
+  Pass bool
+}
+
+var invalid_vertex = errors.New("invalid coordinate")
+
+// Parse a GTP vertex like "D4" or "pass", case insensitive, checking it's
+// inside a board with the given size.
+func ParseVertex(s string, size_y, size_x int) (Vertex, error) {
+  s = strings.ToUpper(s)
+  if s == "PASS" {
+    return Vertex{Pass: true}, nil
+  }
+  digits := strings.IndexAny(s, "0123456789")
+  if digits < 1 {
+    return Vertex{}, invalid_vertex
+  }
+  x, ok := engine.ColumnIndex(s[:digits])
+  row, err := strconv.Atoi(s[digits:])
+  if !ok || err != nil {
+    return Vertex{}, invalid_vertex
+  }
+  if x >= size_x || row < 1 || row > size_y {
+    return Vertex{}, invalid_vertex
+  }
+  return Vertex{Y: row - 1, X: x}, nil
+}
+
+func (v Vertex) String() string {
+  if v.Pass {
+    return "pass"
+  }
+  return fmt.Sprintf("%s%d", engine.ColumnName(v.X), v.Y + 1)
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package gtp
+
+import "testing"
+
+func TestParseVertex(t *testing.T) {
+  testcases := []struct {
+    s string
+    size int
+    expected Vertex
+  } {
+    {"a1", 19, Vertex{0, 0, false}},
+    {"H8", 19, Vertex{7, 7, false}},
+    {"J9", 19, Vertex{8, 8, false}},
+    {"t19", 19, Vertex{18, 18, false}},
+    {"Z25", 25, Vertex{24, 24, false}},
+    {"AA26", 37, Vertex{25, 25, false}},
+    {"am37", 37, Vertex{36, 36, false}},
+    {"PASS", 19, Vertex{0, 0, true}},
+  }
+  for _, tc := range testcases {
+    vertex, err := ParseVertex(tc.s, tc.size, tc.size)
+    if err != nil || vertex != tc.expected {
+      t.Errorf("Parsing %s, expected %v actual %v %v",
+               tc.s, tc.expected, vertex, err)
+    }
+  }
+}
+
+func TestParseInvalidVertex(t *testing.T) {
+  for _, s := range []string{"", "a", "i5", "a0", "k1", "a10", "aa", "a+1",
+                             "1a", "aa1", "a1b"} {
+    if _, err := ParseVertex(s, 9, 9); err == nil {
+      t.Errorf("Expected error parsing %q", s)
+    }
+  }
+}
+
+func TestFormatVertex(t *testing.T) {
+  testcases := []struct {
+    vertex Vertex
+    expected string
+  } {
+    {Vertex{0, 0, false}, "A1"},
+    {Vertex{18, 8, false}, "J19"},
+    {Vertex{24, 24, false}, "Z25"},
+    {Vertex{36, 36, false}, "AM37"},
+    {Vertex{3, 3, true}, "pass"},
+  }
+  for _, tc := range testcases {
+    if actual := tc.vertex.String(); actual != tc.expected {
+      t.Errorf("Expected %s actual %s", tc.expected, actual)
+    }
+  }
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package main
+
+// Run a set of life and death problems and report which ones are solved.
+//
+//   go run problems.go -mode solver -seconds 10 path/to/problems
+
+import "engine"
+import "gtp"
+import "tsumego"
+import "flag"
+import "fmt"
+import "os"
+import "time"
+
+func vertex(move tsumego.Move) string {
+  return gtp.Vertex{Y: move.Y, X: move.X, Pass: move.Pass}.String()
+}
+
+// The first move chosen by the engine, with its Monte Carlo search.
+func engineMove(exercise *tsumego.Exercise, seconds int) (
+    tsumego.Move, error) {
+  y, x, pass := engine.GetBestMove(exercise.State, exercise.ToPlay, seconds)
+  return tsumego.Move{Y: y, X: x, Pass: pass}, nil
+}
+
+// The first move of the solution found by the solver.
+func solverMove(exercise *tsumego.Exercise, seconds int) (
+    tsumego.Move, error) {
+  problem, ok := exercise.Problem()
+  if !ok {
+    return tsumego.Move{}, fmt.Errorf("no target")
+  }
+  solver := &tsumego.Solver{Timeout: time.Duration(seconds) * time.Second}
+  solution, err := solver.Solve(problem)
+  if err != nil {
+    return tsumego.Move{}, err
+  }
+  if solution.Result == tsumego.LOSS {
+    return tsumego.Move{}, fmt.Errorf("no solution")
+  }
+  return solution.Tree.Children[0].Move, nil
+}
+
+func main() {
+  mode := flag.String("mode", "solver", "Either engine or solver.")
+  seconds := flag.Int("seconds", 10, "Time budget for each problem.")
+  flag.Parse()
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: problems [flags] directory")
+    os.Exit(2)
+  }
+  solve := solverMove
+  switch *mode {
+  case "engine":
+    solve = engineMove
+  case "solver":
+  default:
+    fmt.Fprintf(os.Stderr, "unknown mode %q\n", *mode)
+    os.Exit(2)
+  }
+  exercises, err := tsumego.LoadProblemSet(flag.Arg(0))
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    os.Exit(1)
+  }
+  passed := 0
+  var total time.Duration
+  for _, exercise := range exercises {
+    start := time.Now()
+    move, err := solve(exercise, *seconds)
+    elapsed := time.Since(start)
+    total += elapsed
+    status, answer := "FAIL", ""
+    if err != nil {
+      answer = err.Error()
+    } else {
+      answer = vertex(move)
+      if exercise.Correct(move) {
+        status = "PASS"
+        passed++
+      }
+    }
+    fmt.Printf("%-24s %s %-20s %8.3fs\n", exercise.Name, status, answer,
+               elapsed.Seconds())
+  }
+  fmt.Printf("%d/%d passed in %.3fs\n", passed, len(exercises),
+             total.Seconds())
+  if passed < len(exercises) {
+    os.Exit(1)
+  }
+}
@@ -18,8 +18,12 @@ package main
 
 import "gtp"
 import "os"
+import "flag"
 
 func main() {
   var session gtp.Session
+  flag.StringVar(&session.SaveDirectory, "sgf_dir", "",
+                 "Save every game played as SGF in this directory.")
+  flag.Parse()
   session.Run(os.Stdin, os.Stdout)
 }
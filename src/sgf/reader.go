@@ -0,0 +1,428 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package sgf
+
+import "engine"
+import "errors"
+import "fmt"
+import "io"
+import "io/ioutil"
+import "strconv"
+import "strings"
+
+// A node of the game tree. The first child is the main line, the others
+// are variations.
+type Node struct {
+  Properties map[string] []string
+  Children []*Node
+}
+
+func NewNode() *Node {
+  node := new(Node)
+  node.Properties = make(map[string] []string)
+  return node
+}
+
+// Return the first value of a property, if present.
+func (n *Node) Get(property string) (string, bool) {
+  values, ok := n.Properties[property]
+  if !ok || len(values) == 0 {
+    return "", false
+  }
+  return values[0], true
+}
+
+// --------------------------
+// Parser for the SGF FF[4] syntax.
+
+type parser struct {
+  input string
+  pos int
+}
+
+func (p *parser) skipSpaces() {
+  for p.pos < len(p.input) &&
+      strings.IndexByte(" \t\r\n", p.input[p.pos]) >= 0 {
+    p.pos++
+  }
+}
+
+func (p *parser) peek() (byte, bool) {
+  p.skipSpaces()
+  if p.pos >= len(p.input) {
+    return 0, false
+  }
+  return p.input[p.pos], true
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+  return fmt.Errorf("sgf: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// GameTree = "(" Sequence GameTree* ")"
+func (p *parser) parseGameTree() (*Node, error) {
+  if c, ok := p.peek(); !ok || c != '(' {
+    return nil, p.errorf("expected '('")
+  }
+  p.pos++
+  var first, last *Node
+  for {
+    c, ok := p.peek()
+    if !ok {
+      return nil, p.errorf("unexpected end of file")
+    }
+    switch c {
+    case ';':
+      p.pos++
+      node, err := p.parseNode()
+      if err != nil {
+        return nil, err
+      }
+      if first == nil {
+        first = node
+      } else {
+        last.Children = append(last.Children, node)
+      }
+      last = node
+    case '(':
+      if last == nil {
+        return nil, p.errorf("variation before the first node")
+      }
+      child, err := p.parseGameTree()
+      if err != nil {
+        return nil, err
+      }
+      last.Children = append(last.Children, child)
+    case ')':
+      p.pos++
+      if first == nil {
+        return nil, p.errorf("empty game tree")
+      }
+      return first, nil
+    default:
+      return nil, p.errorf("unexpected character %q", c)
+    }
+  }
+}
+
+// Node = ";" { PropIdent PropValue+ }
+func (p *parser) parseNode() (*Node, error) {
+  node := NewNode()
+  for {
+    c, ok := p.peek()
+    if !ok || c == ';' || c == '(' || c == ')' {
+      return node, nil
+    }
+    ident, err := p.parseIdent()
+    if err != nil {
+      return nil, err
+    }
+    for {
+      if c, ok := p.peek(); !ok || c != '[' {
+        break
+      }
+      value, err := p.parseValue()
+      if err != nil {
+        return nil, err
+      }
+      node.Properties[ident] = append(node.Properties[ident], value)
+    }
+    if _, ok := node.Properties[ident]; !ok {
+      return nil, p.errorf("property %s without value", ident)
+    }
+  }
+}
+
+// Lowercase letters in identifiers are ignored, as required by FF[4]
+// for compatibility with older formats (AddBlack is AB).
+func (p *parser) parseIdent() (string, error) {
+  ident := make([]byte, 0, 2)
+  for p.pos < len(p.input) {
+    c := p.input[p.pos]
+    if c >= 'A' && c <= 'Z' {
+      ident = append(ident, c)
+    } else if c < 'a' || c > 'z' {
+      break
+    }
+    p.pos++
+  }
+  if len(ident) == 0 {
+    return "", p.errorf("expected property identifier")
+  }
+  return string(ident), nil
+}
+
+func (p *parser) parseValue() (string, error) {
+  p.pos++
+  value := make([]byte, 0)
+  for p.pos < len(p.input) {
+    c := p.input[p.pos]
+    p.pos++
+    switch c {
+    case ']':
+      return string(value), nil
+    case '\\':
+      if p.pos < len(p.input) {
+        value = append(value, p.input[p.pos])
+        p.pos++
+      }
+    default:
+      value = append(value, c)
+    }
+  }
+  return "", p.errorf("unterminated property value")
+}
+
+// Parse all the game trees in a collection.
+func Parse(input string) ([]*Node, error) {
+  p := &parser{input, 0}
+  games := make([]*Node, 0)
+  for {
+    if _, ok := p.peek(); !ok {
+      break
+    }
+    game, err := p.parseGameTree()
+    if err != nil {
+      return nil, err
+    }
+    games = append(games, game)
+  }
+  if len(games) == 0 {
+    return nil, errors.New("sgf: no game tree found")
+  }
+  return games, nil
+}
+
+func Read(reader io.Reader) ([]*Node, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+  return Parse(string(data))
+}
+
+// --------------------------
+// Conversion to a GameState.
+
+var sgf_rules = map[string] string {
+  "chinese" : "chinese",
+  "japanese" : "japanese",
+  "aga" : "aga",
+  "nz" : "new_zealand",
+  "new_zealand" : "new_zealand",
+}
+
+// SZ is either a single size or columns:rows.
+func boardSize(root *Node) (rows, cols int, err error) {
+  size, ok := root.Get("SZ")
+  if !ok {
+    return 19, 19, nil
+  }
+  parts := strings.Split(size, ":")
+  cols, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+  if err != nil {
+    return 0, 0, fmt.Errorf("sgf: invalid size %q", size)
+  }
+  rows = cols
+  if len(parts) > 1 {
+    rows, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+    if err != nil {
+      return 0, 0, fmt.Errorf("sgf: invalid size %q", size)
+    }
+  }
+  if rows < 1 || cols < 1 || rows > 52 || cols > 52 {
+    return 0, 0, fmt.Errorf("sgf: invalid size %q", size)
+  }
+  return rows, cols, nil
+}
+
+func coordinate(c byte) int {
+  switch {
+  case c >= 'a' && c <= 'z':
+    return int(c - 'a')
+  case c >= 'A' && c <= 'Z':
+    return int(c - 'A') + 26
+  }
+  return -1
+}
+
+// Convert an SGF point to engine coordinates. SGF counts rows from the
+// top, while the engine counts them from the bottom. An empty point, or
+// "tt" on boards up to 19x19, is a pass.
+func Point(value string, rows, cols int) (y, x int, pass bool, err error) {
+  if value == "" || (value == "tt" && rows <= 19 && cols <= 19) {
+    return 0, 0, true, nil
+  }
+  if len(value) != 2 {
+    return 0, 0, false, fmt.Errorf("sgf: invalid point %q", value)
+  }
+  x = coordinate(value[0])
+  row := coordinate(value[1])
+  if x < 0 || row < 0 || x >= cols || row >= rows {
+    return 0, 0, false, fmt.Errorf("sgf: invalid point %q", value)
+  }
+  return rows - 1 - row, x, false, nil
+}
+
+// Expand a list of points, including compressed rectangles like "aa:cc".
+func points(values []string, rows, cols int,
+            callback func (y, x int)) error {
+  for _, value := range values {
+    corners := strings.Split(value, ":")
+    y1, x1, _, err := Point(corners[0], rows, cols)
+    if err != nil {
+      return err
+    }
+    y2, x2 := y1, x1
+    if len(corners) > 1 {
+      y2, x2, _, err = Point(corners[1], rows, cols)
+      if err != nil {
+        return err
+      }
+    }
+    if y1 > y2 {
+      y1, y2 = y2, y1
+    }
+    if x1 > x2 {
+      x1, x2 = x2, x1
+    }
+    for y := y1; y <= y2; y++ {
+      for x := x1; x <= x2; x++ {
+        callback(y, x)
+      }
+    }
+  }
+  return nil
+}
+
+type colorProperty struct {
+  property string
+  color engine.Color
+}
+
+var setup_properties = []colorProperty {
+  {"AE", engine.EMPTY},
+  {"AB", engine.BLACK},
+  {"AW", engine.WHITE},
+}
+
+var move_properties = []colorProperty {
+  {"B", engine.BLACK},
+  {"W", engine.WHITE},
+}
+
+func setupNode(state *engine.GameState, node *Node, rows, cols int) error {
+  for _, setup := range setup_properties {
+    err := points(node.Properties[setup.property], rows, cols,
+                  func (y, x int) {
+      state.Setup(y, x, setup.color)
+    })
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func isMove(node *Node) bool {
+  for _, move := range move_properties {
+    if _, ok := node.Get(move.property); ok {
+      return true
+    }
+  }
+  return false
+}
+
+func playNode(state *engine.GameState, node *Node, rows, cols int) error {
+  for _, move := range move_properties {
+    value, ok := node.Get(move.property)
+    if !ok {
+      continue
+    }
+    y, x, pass, err := Point(value, rows, cols)
+    if err != nil {
+      return err
+    }
+    if pass {
+      state.Pass(move.color)
+    } else if !state.Play(y, x, move.color) {
+      return fmt.Errorf("sgf: illegal move %s[%s]", move.property, value)
+    }
+  }
+  return nil
+}
+
+// Build the game state following the main line of the game. If
+// move_number is positive, stop just before that move is played, as in
+// the GTP loadsgf command.
+func ToGameState(root *Node, move_number int) (*engine.GameState, error) {
+  if format, ok := root.Get("FF"); ok {
+    if ff, err := strconv.Atoi(format); err != nil || ff < 1 || ff > 4 {
+      return nil, fmt.Errorf("sgf: unsupported format FF[%s]", format)
+    }
+  }
+  if game, ok := root.Get("GM"); ok && game != "1" {
+    return nil, fmt.Errorf("sgf: not a go game GM[%s]", game)
+  }
+  rows, cols, err := boardSize(root)
+  if err != nil {
+    return nil, err
+  }
+  state := engine.NewEmptyGameState(rows, cols)
+  state.ClearBoard()
+  state.Komi(0.0)
+  if value, ok := root.Get("KM"); ok {
+    komi, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+    if err != nil {
+      return nil, fmt.Errorf("sgf: invalid komi %q", value)
+    }
+    state.Komi(float32(komi))
+  }
+  if value, ok := root.Get("HA"); ok {
+    handicap, err := strconv.Atoi(strings.TrimSpace(value))
+    if err != nil {
+      return nil, fmt.Errorf("sgf: invalid handicap %q", value)
+    }
+    state.Handicap(handicap)
+  }
+  if value, ok := root.Get("RU"); ok {
+    if name, ok := sgf_rules[strings.ToLower(value)]; ok {
+      rules, _ := engine.RulesetByName(name)
+      state.Rules(rules)
+    }
+  }
+  move := 1
+  for node := root; node != nil; {
+    if err := setupNode(state, node, rows, cols); err != nil {
+      return nil, err
+    }
+    if isMove(node) {
+      if move_number > 0 && move >= move_number {
+        break
+      }
+      if err := playNode(state, node, rows, cols); err != nil {
+        return nil, err
+      }
+      move++
+    }
+    if len(node.Children) == 0 {
+      break
+    }
+    node = node.Children[0]
+  }
+  return state, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package sgf
+
+import "engine"
+import "testing"
+
+const game = "(;FF[4]GM[1]SZ[3]KM[5.5]RU[Japanese]C[a \\] comment]" +
+             "AB[aa]AW[cc];B[ba];W[bb](;B[ab];W[])(;B[ca]))"
+
+func toString(g engine.Goban) string {
+  conv := map[engine.Color] string {
+    engine.EMPTY : ".",
+    engine.BLACK : "x",
+    engine.WHITE : "o",
+  }
+  output := ""
+  for j := g.SizeY() - 1; j >= 0; j-- {
+    for i := 0; i < g.SizeX(); i++ {
+      output += conv[g.GetColor(j, i)]
+    }
+  }
+  return output
+}
+
+func TestParse(t *testing.T) {
+  games, err := Parse(game)
+  if err != nil {
+    t.Fatal(err)
+  }
+  root := games[0]
+  if comment, _ := root.Get("C"); comment != "a ] comment" {
+    t.Errorf("Wrong comment %q", comment)
+  }
+  if len(root.Children) != 1 || len(root.Children[0].Children) != 1 {
+    t.Fatal("Wrong main line")
+  }
+  variations := root.Children[0].Children[0].Children
+  if len(variations) != 2 {
+    t.Fatalf("Expected 2 variations, got %d", len(variations))
+  }
+  if move, _ := variations[1].Get("B"); move != "ca" {
+    t.Errorf("Wrong variation move %q", move)
+  }
+}
+
+func TestParseErrors(t *testing.T) {
+  for _, input := range []string{"", "(", "(;B[aa]", "(;B)", "x"} {
+    if _, err := Parse(input); err == nil {
+      t.Errorf("Expected error parsing %q", input)
+    }
+  }
+}
+
+func TestToGameState(t *testing.T) {
+  games, _ := Parse(game)
+  testcases := []struct {
+    move_number int
+    expected string
+  } {
+    {0, "xx." + "xo." + "..o"},
+    {2, "xx." + "..." + "..o"},
+    {1, "x.." + "..." + "..o"},
+  }
+  for _, tc := range testcases {
+    state, err := ToGameState(games[0], tc.move_number)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if board := toString(state.GetGoban()); board != tc.expected {
+      t.Errorf("Move %d, expected %s actual %s",
+               tc.move_number, tc.expected, board)
+    }
+  }
+}
+
+func TestToGameStateErrors(t *testing.T) {
+  for _, input := range []string{"(;FF[5])", "(;GM[2])", "(;SZ[3];B[zz])",
+                                 "(;SZ[3];B[aa];W[aa])"} {
+    games, err := Parse(input)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if _, err := ToGameState(games[0], 0); err == nil {
+      t.Errorf("Expected error loading %q", input)
+    }
+  }
+}
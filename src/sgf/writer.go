@@ -0,0 +1,138 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package sgf
+
+import "engine"
+import "fmt"
+import "io"
+import "strconv"
+import "strings"
+
+var rules_names = map[string] string {
+  "chinese" : "Chinese",
+  "japanese" : "Japanese",
+  "aga" : "AGA",
+  "new_zealand" : "NZ",
+}
+
+var color_names = map[engine.Color] string {
+  engine.BLACK : "B",
+  engine.WHITE : "W",
+}
+
+func coordinateLetter(c int) byte {
+  if c < 26 {
+    return byte('a' + c)
+  }
+  return byte('A' + c - 26)
+}
+
+// Convert engine coordinates to an SGF point, the inverse of Point.
+func FormatPoint(y, x, rows, cols int) string {
+  return string([]byte{coordinateLetter(x), coordinateLetter(rows - 1 - y)})
+}
+
+func escape(text string) string {
+  text = strings.Replace(text, "\\", "\\\\", -1)
+  return strings.Replace(text, "]", "\\]", -1)
+}
+
+func formatFloat(value float32) string {
+  return strconv.FormatFloat(float64(value), 'f', -1, 32)
+}
+
+// Result in the RE format, like B+3.5, or empty if the game isn't over.
+func Result(state *engine.GameState) string {
+  if !state.GameOver() {
+    return ""
+  }
+  score := state.FinalScore()
+  switch {
+  case score > 0:
+    return "B+" + formatFloat(score)
+  case score < 0:
+    return "W+" + formatFloat(-score)
+  }
+  return "0"
+}
+
+// Append the setup properties of the stones placed after the given number
+// of moves.
+func appendSetup(output []string, setup []engine.MoveRecord, moves int,
+                 rows, cols int) []string {
+  for _, property := range setup_properties {
+    values := make([]string, 0)
+    for _, stone := range setup {
+      if stone.Moves == moves && stone.Color == property.color {
+        values = append(values, FormatPoint(stone.Y, stone.X, rows, cols))
+      }
+    }
+    if len(values) > 0 {
+      output = append(output, property.property + "[" +
+                      strings.Join(values, "][") + "]")
+    }
+  }
+  return output
+}
+
+// Serialise the game played so far, including setup stones and the
+// comments left by the engine on its own moves.
+func Format(state *engine.GameState) string {
+  goban := state.GetGoban()
+  rows, cols := goban.SizeY(), goban.SizeX()
+  output := make([]string, 0)
+  output = append(output, "(;FF[4]GM[1]CA[UTF-8]AP[ricbot:1.0]")
+  if rows == cols {
+    output = append(output, fmt.Sprintf("SZ[%d]", cols))
+  } else {
+    output = append(output, fmt.Sprintf("SZ[%d:%d]", cols, rows))
+  }
+  output = append(output, "KM[" + formatFloat(state.GetKomi()) + "]")
+  if name, ok := rules_names[state.GetRules().Name()]; ok {
+    output = append(output, "RU[" + name + "]")
+  }
+  if handicap := state.GetHandicap(); handicap > 0 {
+    output = append(output, fmt.Sprintf("HA[%d]", handicap))
+  }
+  if result := Result(state); result != "" {
+    output = append(output, "RE[" + result + "]")
+  }
+  setup := state.GetSetup()
+  output = appendSetup(output, setup, 0, rows, cols)
+  for i, move := range state.GetRecord() {
+    point := ""
+    if !move.Pass {
+      point = FormatPoint(move.Y, move.X, rows, cols)
+    }
+    output = append(output, "\n;" + color_names[move.Color] + "[" + point + "]")
+    if move.Comment != "" {
+      output = append(output, "C[" + escape(move.Comment) + "]")
+    }
+    // Setup after a move goes in a node of its own.
+    if next := appendSetup(nil, setup, i + 1, rows, cols); len(next) > 0 {
+      output = append(output, "\n;")
+      output = append(output, next...)
+    }
+  }
+  output = append(output, ")\n")
+  return strings.Join(output, "")
+}
+
+func Write(writer io.Writer, state *engine.GameState) error {
+  _, err := io.WriteString(writer, Format(state))
+  return err
+}
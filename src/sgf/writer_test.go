@@ -0,0 +1,99 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package sgf
+
+import "engine"
+import "testing"
+
+func TestFormatPoint(t *testing.T) {
+  for _, point := range []string{"aa", "ab", "dc", "cd", "Ab"} {
+    y, x, _, err := Point(point, 30, 30)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if actual := FormatPoint(y, x, 30, 30); actual != point {
+      t.Errorf("Expected %s actual %s", point, actual)
+    }
+  }
+}
+
+func TestFormat(t *testing.T) {
+  state := engine.NewEmptyGameState(3, 3)
+  state.ClearBoard()
+  state.Komi(0.5)
+  state.Rules(engine.JapaneseRules{})
+  state.Setup(2, 0, engine.WHITE)
+  state.Play(0, 2, engine.BLACK)
+  state.Pass(engine.WHITE)
+  state.Pass(engine.BLACK)
+  expected := "(;FF[4]GM[1]CA[UTF-8]AP[ricbot:1.0]SZ[3]KM[0.5]RU[Japanese]" +
+              "RE[W+0.5]AW[aa]\n;B[cc]\n;W[]\n;B[])\n"
+  if actual := Format(state); actual != expected {
+    t.Errorf("Expected %q actual %q", expected, actual)
+  }
+}
+
+func TestFormatSetupAfterMoves(t *testing.T) {
+  state := engine.NewEmptyGameState(3, 3)
+  state.ClearBoard()
+  state.Komi(0.5)
+  state.Setup(2, 0, engine.WHITE)
+  state.Play(0, 2, engine.BLACK)
+  // Edits of a point between two moves collapse into the last one.
+  state.Setup(1, 1, engine.BLACK)
+  state.Setup(1, 1, engine.EMPTY)
+  state.Setup(0, 0, engine.BLACK)
+  state.Setup(0, 0, engine.WHITE)
+  state.Setup(2, 0, engine.EMPTY)
+  state.Play(1, 2, engine.WHITE)
+  expected := "(;FF[4]GM[1]CA[UTF-8]AP[ricbot:1.0]SZ[3]KM[0.5]" +
+              "RU[Chinese]AW[aa]\n;B[cc]\n;AE[aa]AW[ac]\n;W[cb])\n"
+  if actual := Format(state); actual != expected {
+    t.Errorf("Expected %q actual %q", expected, actual)
+  }
+  games, err := Parse(expected)
+  if err != nil {
+    t.Fatal(err)
+  }
+  loaded, err := ToGameState(games[0], 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if actual := Format(loaded); actual != expected {
+    t.Errorf("Expected %q actual %q", expected, actual)
+  }
+}
+
+func TestRoundTrip(t *testing.T) {
+  state := engine.NewEmptyGameState(3, 4)
+  state.ClearBoard()
+  state.Komi(6.5)
+  state.Setup(1, 1, engine.BLACK)
+  state.Play(2, 3, engine.WHITE)
+  state.Play(0, 0, engine.BLACK)
+  games, err := Parse(Format(state))
+  if err != nil {
+    t.Fatal(err)
+  }
+  loaded, err := ToGameState(games[0], 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if Format(loaded) != Format(state) {
+    t.Errorf("Expected %q actual %q", Format(state), Format(loaded))
+  }
+}
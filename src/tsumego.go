@@ -18,6 +18,7 @@ package main
 
 import . "engine"
 import "fmt"
+import "tsumego"
 
 func main() {
   state := NewGameState(7, 7, 0.0, "......." +
@@ -27,6 +28,26 @@ func main() {
                                    "..oxxx." +
                                    "ooo.oo." +
                                    "...o...")
-  y, x := GetBestMove(state, BLACK, 30)
-  fmt.Printf("move %d %d\n", y, x)
+  // The fight is on the bottom four rows.
+  region := make([]bool, 7 * 7)
+  for i := 0; i < 4 * 7; i++ {
+    region[i] = true
+  }
+  solution, err := tsumego.Solve(&tsumego.Problem{
+    Goban: state.GetGoban(),
+    TargetY: 1, TargetX: 3,
+    ToPlay: BLACK,
+    Goal: tsumego.KILL,
+    Region: region,
+  })
+  if err != nil {
+    fmt.Println(err)
+    return
+  }
+  if solution.Result == tsumego.LOSS {
+    fmt.Println("no solution")
+    return
+  }
+  move := solution.Tree.Children[0].Move
+  fmt.Printf("%v: move %d %d\n", solution.Result, move.Y, move.X)
 }
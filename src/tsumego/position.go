@@ -0,0 +1,157 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package tsumego
+
+import "engine"
+import "hash/fnv"
+
+// A move of the solution, at Y, X unless it's a pass.
+type Move struct {
+  Y, X int
+  Pass bool
+}
+
+// A position of the search. Ko is handled by forbidding moves that
+// repeat a position of the current line, so the position only needs the
+// stones, the player to move, and how the line is going to end.
+type position struct {
+  goban *engine.ChainGoban
+  to_play engine.Color
+  // Consecutive passes just played. Two passes end the line.
+  passes int
+  // Ko threats left for the side proving the goal.
+  threats int
+  // Hash of the stones, computed on first use.
+  board uint64
+  hashed bool
+  // Smallest hash of the stones under the symmetries of the problem,
+  // computed on first use.
+  canonical uint64
+  canonized bool
+}
+
+var neighbour_dy = []int{-1, 1, 0, 0}
+var neighbour_dx = []int{0, 0, -1, 1}
+
+func onBoard(g engine.Goban, y, x int) bool {
+  return y >= 0 && y < g.SizeY() && x >= 0 && x < g.SizeX()
+}
+
+// The position after move, which must be legal.
+func (p *position) play(move Move) *position {
+  next := &position{
+    goban: p.goban.Copy().(*engine.ChainGoban),
+    to_play: engine.Opposite(p.to_play),
+    threats: p.threats,
+  }
+  if move.Pass {
+    next.passes = p.passes + 1
+    next.board, next.hashed = p.board, p.hashed
+    next.canonical, next.canonized = p.canonical, p.canonized
+    return next
+  }
+  g := next.goban
+  g.SetColor(move.Y, move.X, p.to_play)
+  for i := range neighbour_dy {
+    ny, nx := move.Y + neighbour_dy[i], move.X + neighbour_dx[i]
+    if onBoard(g, ny, nx) && g.GetColor(ny, nx) == next.to_play &&
+       !g.HasLiberties(ny, nx) {
+      g.RemoveGroup(ny, nx)
+    }
+  }
+  return next
+}
+
+func (p *position) hashBoard() uint64 {
+  if p.hashed {
+    return p.board
+  }
+  hash := fnv.New64a()
+  g := p.goban
+  buf := make([]byte, g.SizeX())
+  for y := 0; y < g.SizeY(); y++ {
+    for x := 0; x < g.SizeX(); x++ {
+      buf[x] = byte(g.GetColor(y, x))
+    }
+    hash.Write(buf)
+  }
+  p.board, p.hashed = hash.Sum64(), true
+  return p.board
+}
+
+// Hash of the stones and the player to move, used to detect repetitions.
+func (p *position) situation() uint64 {
+  return p.hashBoard() * 3 + uint64(p.to_play)
+}
+
+// Hash of everything that changes the value of the position.
+func (p *position) key() uint64 {
+  return p.extend(p.situation())
+}
+
+// As key, but the same for all positions that are symmetric under the
+// given symmetries, which must keep the value of the problem.
+func (p *position) canonicalKey(symmetries []engine.Symmetry) uint64 {
+  if !p.canonized {
+    p.canonical, _ = engine.CanonicalHash(p.goban, symmetries)
+    p.canonized = true
+  }
+  return p.extend(p.canonical * 3 + uint64(p.to_play))
+}
+
+func (p *position) extend(situation uint64) uint64 {
+  return (situation * 3 + uint64(p.passes)) * 31 + uint64(p.threats)
+}
+
+// The points reachable from the stone at y, x without crossing stones of
+// the other color. For an enclosed group, the moves of the problem are
+// the empty points of this region.
+func Enclosure(g engine.Goban, y, x int) []bool {
+  attacker := engine.Opposite(g.GetColor(y, x))
+  return fill(g, y, x, func (color engine.Color) bool {
+    return color != attacker && color != engine.INVALID
+  })
+}
+
+// The stones of the group at y, x.
+func group(g engine.Goban, y, x int) []bool {
+  own := g.GetColor(y, x)
+  return fill(g, y, x, func (color engine.Color) bool {
+    return color == own
+  })
+}
+
+// The points reachable from y, x through points whose color is inside.
+func fill(g engine.Goban, y, x int,
+          inside func (color engine.Color) bool) []bool {
+  region := make([]bool, g.SizeY() * g.SizeX())
+  stack := []Move{{Y: y, X: x}}
+  region[y * g.SizeX() + x] = true
+  for len(stack) > 0 {
+    p := stack[len(stack) - 1]
+    stack = stack[:len(stack) - 1]
+    for i := range neighbour_dy {
+      ny, nx := p.Y + neighbour_dy[i], p.X + neighbour_dx[i]
+      if onBoard(g, ny, nx) && !region[ny * g.SizeX() + nx] &&
+         inside(g.GetColor(ny, nx)) {
+        region[ny * g.SizeX() + nx] = true
+        stack = append(stack, Move{Y: ny, X: nx})
+      }
+    }
+  }
+  return region
+}
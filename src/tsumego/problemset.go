@@ -0,0 +1,291 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package tsumego
+
+import "engine"
+import "sgf"
+import "fmt"
+import "io"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "sort"
+import "strconv"
+import "strings"
+import "unicode"
+
+// --------------------------
+// Problem sets. A problem set is a directory with one problem per file,
+// either SGF (.sgf) or ASCII (.txt), each with its correct first moves.
+//
+// In SGF files the setup stones are in the root node, and each variation
+// starting at the root is a first move. A variation is correct if any of
+// its nodes has a TE property, or a comment starting with "Correct" or
+// "RIGHT", and a file without a correct variation is rejected. The
+// player to move is PL, or the color of the first variation. A stone marked with MA or TR is the target for the solver.
+//
+// ASCII files have a few keyword lines followed by the board, drawn as in
+// engine.ParseShape, with the first line being row 1:
+//
+//   to_play black
+//   answer c3 b1
+//   target b2
+//   ..xxx.
+//   .xooox
+//   ...
+//
+// Moves are GTP vertices. The answer line is required, and to_play
+// defaults to black.
+
+// A problem with its expected answers.
+type Exercise struct {
+  Name string
+  State *engine.GameState
+  ToPlay engine.Color
+  Answers []Move
+  // The group of the problem, if known.
+  HasTarget bool
+  TargetY, TargetX int
+}
+
+func (e *Exercise) Correct(move Move) bool {
+  for _, answer := range e.Answers {
+    if answer == move {
+      return true
+    }
+  }
+  return false
+}
+
+// The problem for the solver. The goal is to kill the target if it
+// belongs to the opponent of the player to move, or to make it live.
+func (e *Exercise) Problem() (*Problem, bool) {
+  if !e.HasTarget {
+    return nil, false
+  }
+  g := e.State.GetGoban()
+  goal := Goal(LIVE)
+  if g.GetColor(e.TargetY, e.TargetX) != e.ToPlay {
+    goal = KILL
+  }
+  return &Problem{
+    Goban: g,
+    TargetY: e.TargetY,
+    TargetX: e.TargetX,
+    ToPlay: e.ToPlay,
+    Goal: goal,
+    Region: Enclosure(g, e.TargetY, e.TargetX),
+  }, true
+}
+
+// Load every problem in the directory, sorted by file name.
+func LoadProblemSet(dir string) ([]*Exercise, error) {
+  names, err := filepath.Glob(filepath.Join(dir, "*"))
+  if err != nil {
+    return nil, err
+  }
+  sort.Strings(names)
+  exercises := []*Exercise{}
+  for _, name := range names {
+    var read func (string, io.Reader) (*Exercise, error)
+    switch strings.ToLower(filepath.Ext(name)) {
+    case ".sgf":
+      read = ReadSGF
+    case ".txt":
+      read = ReadASCII
+    default:
+      continue
+    }
+    file, err := os.Open(name)
+    if err != nil {
+      return nil, err
+    }
+    exercise, err := read(filepath.Base(name), file)
+    file.Close()
+    if err != nil {
+      return nil, fmt.Errorf("%s: %v", name, err)
+    }
+    exercises = append(exercises, exercise)
+  }
+  return exercises, nil
+}
+
+func parseColor(s string) (engine.Color, error) {
+  switch strings.ToLower(s) {
+  case "b", "black":
+    return engine.BLACK, nil
+  case "w", "white":
+    return engine.WHITE, nil
+  }
+  return engine.EMPTY, fmt.Errorf("tsumego: invalid color %q", s)
+}
+
+// Parse a GTP vertex.
+func parseMove(s string, rows, cols int) (Move, error) {
+  s = strings.ToUpper(s)
+  if s == "PASS" {
+    return Move{Pass: true}, nil
+  }
+  digits := strings.IndexAny(s, "0123456789")
+  if digits >= 1 {
+    x, ok := engine.ColumnIndex(s[:digits])
+    row, err := strconv.Atoi(s[digits:])
+    if ok && err == nil && x < cols && row >= 1 && row <= rows {
+      return Move{Y: row - 1, X: x}, nil
+    }
+  }
+  return Move{}, fmt.Errorf("tsumego: invalid vertex %q", s)
+}
+
+func ReadASCII(name string, reader io.Reader) (*Exercise, error) {
+  data, err := ioutil.ReadAll(reader)
+  if err != nil {
+    return nil, err
+  }
+  exercise := &Exercise{Name: name, ToPlay: engine.BLACK}
+  keywords := make(map[string] []string)
+  board := []string{}
+  for _, line := range strings.Split(string(data), "\n") {
+    fields := strings.Fields(line)
+    if len(fields) > 0 {
+      switch fields[0] {
+      case "to_play", "answer", "target":
+        keywords[fields[0]] = fields[1:]
+        continue
+      }
+    }
+    board = append(board, line)
+  }
+  exercise.State, err = engine.NewShapedGameState(
+      engine.CHAIN_GOBAN, strings.Join(board, "\n"), 0.0)
+  if err != nil {
+    return nil, err
+  }
+  g := exercise.State.GetGoban()
+  if values, ok := keywords["to_play"]; ok && len(values) > 0 {
+    if exercise.ToPlay, err = parseColor(values[0]); err != nil {
+      return nil, err
+    }
+  }
+  for _, value := range keywords["answer"] {
+    move, err := parseMove(value, g.SizeY(), g.SizeX())
+    if err != nil {
+      return nil, err
+    }
+    exercise.Answers = append(exercise.Answers, move)
+  }
+  if len(exercise.Answers) == 0 {
+    return nil, fmt.Errorf("tsumego: no answer")
+  }
+  if values, ok := keywords["target"]; ok && len(values) > 0 {
+    move, err := parseMove(values[0], g.SizeY(), g.SizeX())
+    if err != nil || move.Pass {
+      return nil, fmt.Errorf("tsumego: invalid target %q", values[0])
+    }
+    exercise.HasTarget = true
+    exercise.TargetY, exercise.TargetX = move.Y, move.X
+  }
+  return exercise, nil
+}
+
+// markedCorrect reports whether a variation is marked as an answer, either
+// with a TE (good move) property or with a comment whose first word is
+// "Correct" or "RIGHT". Only the first word counts, so "Incorrect", "not
+// correct" or "upper right" don't mark a variation.
+func markedCorrect(node *sgf.Node) bool {
+  if _, ok := node.Get("TE"); ok {
+    return true
+  }
+  comment, _ := node.Get("C")
+  words := strings.FieldsFunc(strings.ToLower(comment), func(r rune) bool {
+    return !unicode.IsLetter(r)
+  })
+  return len(words) > 0 && (words[0] == "correct" || words[0] == "right")
+}
+
+// Check if any node of the variation is marked, since many collections
+// only mark the last node of the correct line.
+func correctLine(node *sgf.Node) bool {
+  if markedCorrect(node) {
+    return true
+  }
+  for _, child := range node.Children {
+    if correctLine(child) {
+      return true
+    }
+  }
+  return false
+}
+
+func ReadSGF(name string, reader io.Reader) (*Exercise, error) {
+  games, err := sgf.Read(reader)
+  if err != nil {
+    return nil, err
+  }
+  root := games[0]
+  exercise := &Exercise{Name: name, ToPlay: engine.EMPTY}
+  // Stop before the first move, keeping only the setup.
+  exercise.State, err = sgf.ToGameState(root, 1)
+  if err != nil {
+    return nil, err
+  }
+  g := exercise.State.GetGoban()
+  rows, cols := g.SizeY(), g.SizeX()
+  if value, ok := root.Get("PL"); ok {
+    if exercise.ToPlay, err = parseColor(value); err != nil {
+      return nil, err
+    }
+  }
+  for _, child := range root.Children {
+    for _, property := range []string{"B", "W"} {
+      value, ok := child.Get(property)
+      if !ok {
+        continue
+      }
+      if exercise.ToPlay == engine.EMPTY {
+        exercise.ToPlay, _ = parseColor(property)
+      }
+      y, x, pass, err := sgf.Point(value, rows, cols)
+      if err != nil {
+        return nil, err
+      }
+      if correctLine(child) {
+        exercise.Answers = append(exercise.Answers,
+                                  Move{Y: y, X: x, Pass: pass})
+      }
+    }
+  }
+  // Without a marked line every first move would be accepted.
+  if len(exercise.Answers) == 0 {
+    return nil, fmt.Errorf("tsumego: no marked answer")
+  }
+  if exercise.ToPlay == engine.EMPTY {
+    exercise.ToPlay = engine.BLACK
+  }
+  for _, property := range []string{"MA", "TR"} {
+    if value, ok := root.Get(property); ok {
+      y, x, pass, err := sgf.Point(value, rows, cols)
+      if err != nil || pass {
+        return nil, fmt.Errorf("tsumego: invalid target %q", value)
+      }
+      exercise.HasTarget = true
+      exercise.TargetY, exercise.TargetX = y, x
+      break
+    }
+  }
+  return exercise, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package tsumego
+
+import "engine"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+const ascii_problem = "to_play black\n" +
+                      "answer c3\n" +
+                      "target c2\n" +
+                      "..xxx.\n" +
+                      ".xooox\n" +
+                      "xo..ox\n" +
+                      "xo.oox\n" +
+                      "xooox.\n" +
+                      ".xxx.x\n"
+
+// The same problem with white to live, and a wrong variation.
+const sgf_problem = "(;FF[4]GM[1]SZ[6]PL[W]MA[cb]" +
+                    "AB[cf][df][ef][be][fe][ad][fd][ac][fc][ab][eb]" +
+                    "[ba][ca][da][fa]" +
+                    "AW[ce][de][ee][bd][ed][bc][dc][ec][bb][cb][db]" +
+                    "(;W[cd]C[Correct])(;W[dd]))"
+
+func TestReadASCII(t *testing.T) {
+  exercise, err := ReadASCII("bent", strings.NewReader(ascii_problem))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if exercise.ToPlay != engine.BLACK || len(exercise.Answers) != 1 ||
+     !exercise.Correct(Move{Y: 2, X: 2}) || !exercise.HasTarget ||
+     exercise.TargetY != 1 || exercise.TargetX != 2 {
+    t.Errorf("Wrong exercise %+v", exercise)
+  }
+  problem, ok := exercise.Problem()
+  if !ok || problem.Goal != KILL {
+    t.Errorf("Expected a kill problem, actual %+v", problem)
+  }
+  _, err = ReadASCII("bent", strings.NewReader("to_play black\n.x\n"))
+  if err == nil {
+    t.Errorf("Expected an error without answers")
+  }
+}
+
+func TestReadSGF(t *testing.T) {
+  exercise, err := ReadSGF("bent", strings.NewReader(sgf_problem))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if exercise.ToPlay != engine.WHITE || len(exercise.Answers) != 1 ||
+     !exercise.Correct(Move{Y: 2, X: 2}) ||
+     exercise.Correct(Move{Y: 2, X: 3}) {
+    t.Errorf("Wrong exercise %+v", exercise)
+  }
+  g := exercise.State.GetGoban()
+  expected := engine.NewChainGoban(6, 6)
+  engine.FromString(expected, bent_three)
+  if engine.RenderGoban(g) != engine.RenderGoban(expected) {
+    t.Errorf("Wrong board\n%s", engine.RenderGoban(g))
+  }
+  problem, ok := exercise.Problem()
+  if !ok || problem.Goal != LIVE || problem.TargetY != 4 ||
+     problem.TargetX != 2 {
+    t.Errorf("Expected a live problem, actual %+v", problem)
+  }
+  solution, err := Solve(problem)
+  if err != nil || solution.Result != WIN ||
+     !exercise.Correct(solution.Tree.Children[0].Move) {
+    t.Errorf("Wrong solution %+v %v", solution, err)
+  }
+}
+
+func TestReadSGFMarkers(t *testing.T) {
+  problem := "(;FF[4]GM[1]SZ[6]PL[W]AB[cf]AW[ce]" +
+             "(;W[cd]C[Incorrect])(;W[dd]C[RIGHT! Well done])" +
+             "(;W[ee]C[Threat in the upper right])(;W[bb]C[not correct])" +
+             "(;W[aa]TE[1]))"
+  exercise, err := ReadSGF("markers", strings.NewReader(problem))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(exercise.Answers) != 2 || !exercise.Correct(Move{Y: 2, X: 3}) ||
+     !exercise.Correct(Move{Y: 5, X: 0}) ||
+     exercise.Correct(Move{Y: 2, X: 2}) ||
+     exercise.Correct(Move{Y: 1, X: 4}) ||
+     exercise.Correct(Move{Y: 4, X: 1}) {
+    t.Errorf("Wrong answers %+v", exercise.Answers)
+  }
+}
+
+func TestReadSGFMarkedLine(t *testing.T) {
+  // Only the last node of the correct line is marked, and the wrong line
+  // ends without a marker.
+  problem := "(;FF[4]GM[1]SZ[6]PL[W]AB[cf]AW[ce]" +
+             "(;W[cd];B[dd];W[de]C[RIGHT])" +
+             "(;W[bb];B[cd];W[dd]C[Black lives]))"
+  exercise, err := ReadSGF("line", strings.NewReader(problem))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(exercise.Answers) != 1 || !exercise.Correct(Move{Y: 2, X: 2}) ||
+     exercise.Correct(Move{Y: 4, X: 1}) {
+    t.Errorf("Wrong answers %+v", exercise.Answers)
+  }
+  unmarked := "(;FF[4]GM[1]SZ[6]PL[W]AB[cf]AW[ce](;W[cd])(;W[bb]))"
+  if _, err := ReadSGF("unmarked", strings.NewReader(unmarked)); err == nil {
+    t.Errorf("Expected an error without marked answers")
+  }
+}
+
+func TestLoadProblemSet(t *testing.T) {
+  dir, err := ioutil.TempDir("", "tsumego")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+  files := map[string] string {
+    "b.sgf" : sgf_problem,
+    "a.txt" : ascii_problem,
+    "readme" : "not a problem",
+  }
+  for name, content := range files {
+    path := filepath.Join(dir, name)
+    if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  exercises, err := LoadProblemSet(dir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(exercises) != 2 || exercises[0].Name != "a.txt" ||
+     exercises[1].Name != "b.sgf" {
+    t.Errorf("Wrong problem set %v", exercises)
+  }
+}
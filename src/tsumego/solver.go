@@ -0,0 +1,375 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+// Package tsumego solves life and death problems exactly, with a
+// depth-first proof-number search (df-pn) restricted to a region of the
+// board.
+package tsumego
+
+import "engine"
+import "errors"
+import "time"
+
+// Goal of the side the problem is about.
+const (
+  // The attacker captures the target group.
+  KILL = iota
+  // The defender keeps the target group on the board.
+  LIVE
+)
+
+type Goal int
+
+// Outcome for the side pursuing the goal.
+const (
+  LOSS = iota
+  // The goal is reached only by winning a ko.
+  KO
+  WIN
+)
+
+type Result int
+
+func (r Result) String() string {
+  switch r {
+  case WIN:
+    return "win"
+  case KO:
+    return "ko"
+  }
+  return "loss"
+}
+
+// A life and death problem. The target is a stone of the group being
+// attacked, so the defender is its color and the attacker the other one.
+type Problem struct {
+  Goban engine.Goban
+  TargetY, TargetX int
+  ToPlay engine.Color
+  Goal Goal
+  // Points where moves are allowed, indexed by y * SizeX() + x. Passing
+  // is always allowed. A nil region allows the whole board.
+  Region []bool
+}
+
+// A node of the solution tree. The children of a move by the side
+// pursuing the goal are all the answers of the opponent, and the children
+// of an answer are the single move that keeps the goal.
+type Node struct {
+  Move Move
+  Color engine.Color
+  Children []*Node
+}
+
+type Solution struct {
+  Result Result
+  // The root has no move. Its children are the first moves of the side
+  // that wins the problem, or all of them if that side isn't to play.
+  Tree *Node
+  // Positions searched.
+  Nodes int
+}
+
+var ErrBudget = errors.New("search budget exceeded")
+var ErrTarget = errors.New("target is not a stone")
+
+// Number of times the side pursuing the goal may retake a ko before the
+// result is reported as a ko.
+const ko_threats = 2
+
+// Proof and disproof numbers of proven positions.
+const infinity = 1 << 30
+
+// Proof numbers are stored from the point of view of the player to move:
+// phi is the proof number of a win for it, and delta the disproof number.
+type entry struct {
+  phi, delta int
+}
+
+type Solver struct {
+  // Search limits, ignored when zero.
+  MaxNodes int
+  Timeout time.Duration
+
+  problem *Problem
+  attacker, defender, prover engine.Color
+  target int
+  // Symmetries of the board that keep the value of every position. The
+  // table is shared by symmetric positions, but the subtrees hold moves,
+  // so they stay in the orientation of the position.
+  symmetries []engine.Symmetry
+  table map[uint64]entry
+  subtrees map[uint64][]*Node
+  // Situations of the current line, for the ko rule.
+  path map[uint64]int
+  nodes int
+  deadline time.Time
+  stopped bool
+}
+
+func Solve(problem *Problem) (*Solution, error) {
+  return new(Solver).Solve(problem)
+}
+
+func (s *Solver) Solve(problem *Problem) (*Solution, error) {
+  g := problem.Goban
+  s.defender = g.GetColor(problem.TargetY, problem.TargetX)
+  if s.defender != engine.BLACK && s.defender != engine.WHITE {
+    return nil, ErrTarget
+  }
+  s.problem = problem
+  s.attacker = engine.Opposite(s.defender)
+  s.prover = s.attacker
+  if problem.Goal == LIVE {
+    s.prover = s.defender
+  }
+  s.target = problem.TargetY * g.SizeX() + problem.TargetX
+  s.symmetries = problemSymmetries(problem)
+  s.table = make(map[uint64]entry)
+  s.path = make(map[uint64]int)
+  s.subtrees = make(map[uint64][]*Node)
+  s.nodes = 0
+  s.stopped = false
+  if s.Timeout > 0 {
+    s.deadline = time.Now().Add(s.Timeout)
+  }
+  root := &position{goban: engine.NewChainGobanFrom(g),
+                    to_play: problem.ToPlay}
+  // First without ko threats, then with them.
+  for _, result := range []Result{WIN, KO} {
+    if result == KO {
+      root.threats = ko_threats
+    }
+    phi, delta := s.mid(root, infinity, infinity)
+    if s.stopped {
+      return nil, ErrBudget
+    }
+    proven := phi == 0
+    if root.to_play != s.prover {
+      proven = delta == 0
+    }
+    if proven || result == KO {
+      if !proven {
+        result = LOSS
+      }
+      return &Solution{result, s.tree(root, Move{}, engine.EMPTY), s.nodes},
+             nil
+    }
+  }
+  panic("unreachable")
+}
+
+// The symmetries that keep the region, and take the target to a stone of
+// its own group. Stones of a group are captured together, so symmetric
+// positions have the same value.
+func problemSymmetries(problem *Problem) []engine.Symmetry {
+  g := problem.Goban
+  size_y, size_x := g.SizeY(), g.SizeX()
+  target := group(g, problem.TargetY, problem.TargetX)
+  symmetries := []engine.Symmetry{}
+  for _, symmetry := range engine.Symmetries(size_y, size_x) {
+    ty, tx := symmetry.Point(size_y, size_x, problem.TargetY,
+                             problem.TargetX)
+    same := target[ty * size_x + tx]
+    for y := 0; same && problem.Region != nil && y < size_y; y++ {
+      for x := 0; x < size_x; x++ {
+        sy, sx := symmetry.Point(size_y, size_x, y, x)
+        same = same && problem.Region[y * size_x + x] ==
+                       problem.Region[sy * size_x + sx]
+      }
+    }
+    if same {
+      symmetries = append(symmetries, symmetry)
+    }
+  }
+  return symmetries
+}
+
+// The winner of a finished line. The attacker wins by capturing the
+// target, and the defender when the target is unconditionally alive or
+// both players pass.
+func (s *Solver) winner(p *position) (engine.Color, bool) {
+  g := p.goban
+  sx := g.SizeX()
+  if g.GetColor(s.target / sx, s.target % sx) != s.defender {
+    return s.attacker, true
+  }
+  if p.passes >= 2 {
+    return s.defender, true
+  }
+  // Unconditional life needs two liberties.
+  if !g.InAtari(s.target / sx, s.target % sx) {
+    if alive, _ := engine.Benson(g, s.defender); alive[s.target] {
+      return s.defender, true
+    }
+  }
+  return engine.EMPTY, false
+}
+
+// Legal moves inside the region, plus pass. Moves that repeat a position
+// of the current line are only allowed while the side pursuing the goal
+// has ko threats, and each one uses a threat.
+func (s *Solver) children(p *position) ([]Move, []*position) {
+  g := p.goban
+  moves := []Move{}
+  children := []*position{}
+  for y := 0; y < g.SizeY(); y++ {
+    for x := 0; x < g.SizeX(); x++ {
+      if s.problem.Region != nil && !s.problem.Region[y * g.SizeX() + x] ||
+         g.GetColor(y, x) != engine.EMPTY || g.Suicide(y, x, p.to_play) {
+        continue
+      }
+      move := Move{Y: y, X: x}
+      child := p.play(move)
+      if s.path[child.situation()] > 0 {
+        if p.to_play != s.prover || p.threats == 0 {
+          continue
+        }
+        child.threats--
+      }
+      moves = append(moves, move)
+      children = append(children, child)
+    }
+  }
+  pass := Move{Pass: true}
+  return append(moves, pass), append(children, p.play(pass))
+}
+
+func (s *Solver) lookup(p *position) (phi, delta int) {
+  if e, ok := s.table[p.canonicalKey(s.symmetries)]; ok {
+    return e.phi, e.delta
+  }
+  return 1, 1
+}
+
+func (s *Solver) exhausted() bool {
+  if s.MaxNodes > 0 && s.nodes >= s.MaxNodes {
+    s.stopped = true
+  }
+  if !s.deadline.IsZero() && s.nodes % 1024 == 0 &&
+     time.Now().After(s.deadline) {
+    s.stopped = true
+  }
+  return s.stopped
+}
+
+func add(a, b int) int {
+  if a + b > infinity {
+    return infinity
+  }
+  return a + b
+}
+
+func min(a, b int) int {
+  if a < b {
+    return a
+  }
+  return b
+}
+
+// Multiple iterative deepening: search p until its proof or disproof
+// number reaches the thresholds. Results are cached without the line
+// that led to them, which can be wrong when a repetition was involved,
+// the usual compromise of proof-number search.
+func (s *Solver) mid(p *position, th_phi, th_delta int) (phi, delta int) {
+  key := p.canonicalKey(s.symmetries)
+  if e, ok := s.table[key]; ok && (e.phi >= th_phi || e.delta >= th_delta) {
+    return e.phi, e.delta
+  }
+  s.nodes++
+  if winner, ok := s.winner(p); ok {
+    e := entry{infinity, 0}
+    if winner == p.to_play {
+      e = entry{0, infinity}
+    }
+    s.table[key] = e
+    return e.phi, e.delta
+  }
+  if s.exhausted() {
+    return s.lookup(p)
+  }
+  situation := p.situation()
+  s.path[situation]++
+  defer func() { s.path[situation]-- }()
+  _, children := s.children(p)
+  for {
+    // The player to move wins through any child, and loses through all.
+    phi, delta = infinity, 0
+    best, best_phi, delta2 := 0, 0, infinity
+    for i, child := range children {
+      child_phi, child_delta := s.lookup(child)
+      if child_delta < phi {
+        delta2 = phi
+        phi, best, best_phi = child_delta, i, child_phi
+      } else if child_delta < delta2 {
+        delta2 = child_delta
+      }
+      delta = add(delta, child_phi)
+    }
+    if phi >= th_phi || delta >= th_delta || s.stopped {
+      s.table[key] = entry{phi, delta}
+      return phi, delta
+    }
+    s.mid(children[best], min(add(th_delta, best_phi) - delta, infinity),
+          min(th_phi, add(delta2, 1)))
+  }
+}
+
+// Build the solution tree below a proven position. Transpositions share
+// their subtrees, so the tree stays as small as the proof.
+func (s *Solver) tree(p *position, move Move, color engine.Color) *Node {
+  node := &Node{Move: move, Color: color}
+  if _, ok := s.winner(p); ok {
+    return node
+  }
+  key := p.key()
+  if children, ok := s.subtrees[key]; ok {
+    node.Children = children
+    return node
+  }
+  situation := p.situation()
+  s.path[situation]++
+  defer func() { s.path[situation]-- }()
+  moves, children := s.children(p)
+  if phi, _ := s.lookup(p); phi == 0 {
+    // Only one winning move is needed, preferably one the search proved.
+    best := -1
+    for i, child := range children {
+      if _, delta := s.lookup(child); delta == 0 {
+        best = i
+        break
+      }
+    }
+    for i := 0; best < 0 && i < len(children); i++ {
+      if _, delta := s.mid(children[i], infinity, infinity); delta == 0 {
+        best = i
+      }
+    }
+    if best >= 0 {
+      node.Children = []*Node{s.tree(children[best], moves[best], p.to_play)}
+    }
+  } else {
+    for i, child := range children {
+      if phi, _ := s.lookup(child); phi != 0 {
+        s.mid(child, infinity, infinity)
+      }
+      node.Children = append(node.Children,
+                             s.tree(child, moves[i], p.to_play))
+    }
+  }
+  s.subtrees[key] = node.Children
+  return node
+}
@@ -0,0 +1,161 @@
+// Copyright (C) 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Author: Ricardo Bittencourt (bluepenguin@gmail.com)
+
+package tsumego
+
+import "engine"
+import "fmt"
+import "testing"
+
+// White has a bent three, killed by the vital point at 2, 2.
+const bent_three = "..xxx." +
+                   ".xooox" +
+                   "xo..ox" +
+                   "xo.oox" +
+                   "xooox." +
+                   ".xxx.x"
+
+// Black can only capture the corner by winning a ko.
+const corner_ko = "oox.x" +
+                  ".o..o" +
+                  ".ooxo"
+
+func newProblem(rows, cols int, board string, to_play engine.Color,
+                goal Goal) *Problem {
+  g := engine.NewChainGoban(rows, cols)
+  engine.FromString(g, board)
+  return &Problem{Goban: g, ToPlay: to_play, Goal: goal}
+}
+
+func TestSolve(t *testing.T) {
+  testcases := []struct {
+    board string
+    rows, cols int
+    target Move
+    to_play engine.Color
+    goal Goal
+    enclosed bool
+    expected Result
+  } {
+    {bent_three, 6, 6, Move{Y: 1, X: 2}, engine.BLACK, KILL, true, WIN},
+    {bent_three, 6, 6, Move{Y: 1, X: 2}, engine.WHITE, KILL, true, LOSS},
+    {bent_three, 6, 6, Move{Y: 1, X: 2}, engine.WHITE, LIVE, true, WIN},
+    {bent_three, 6, 6, Move{Y: 1, X: 2}, engine.BLACK, LIVE, true, LOSS},
+    {corner_ko, 3, 5, Move{Y: 0, X: 0}, engine.BLACK, KILL, false, KO},
+    {corner_ko, 3, 5, Move{Y: 0, X: 0}, engine.WHITE, KILL, false, LOSS},
+  }
+  for _, tc := range testcases {
+    problem := newProblem(tc.rows, tc.cols, tc.board, tc.to_play, tc.goal)
+    problem.TargetY, problem.TargetX = tc.target.Y, tc.target.X
+    if tc.enclosed {
+      problem.Region = Enclosure(problem.Goban, tc.target.Y, tc.target.X)
+    }
+    solution, err := Solve(problem)
+    if err != nil || solution.Result != tc.expected {
+      t.Errorf("Solving %v to play, goal %v, expected %v actual %v %v",
+               tc.to_play, tc.goal, tc.expected, solution, err)
+    }
+  }
+}
+
+func TestSolutionTree(t *testing.T) {
+  problem := newProblem(6, 6, bent_three, engine.BLACK, KILL)
+  problem.TargetY, problem.TargetX = 1, 2
+  problem.Region = Enclosure(problem.Goban, 1, 2)
+  solution, err := Solve(problem)
+  if err != nil {
+    t.Fatal(err)
+  }
+  root := solution.Tree
+  if len(root.Children) != 1 ||
+     root.Children[0].Move != (Move{Y: 2, X: 2}) ||
+     root.Children[0].Color != engine.BLACK {
+    t.Fatalf("Expected black at 2, 2, actual %v", root.Children)
+  }
+  // Every white answer has a black reply, down to the end of the line.
+  var check func (node *Node, prover bool)
+  check = func (node *Node, prover bool) {
+    if prover && len(node.Children) > 1 {
+      t.Errorf("Expected a single reply to %v", node.Move)
+    }
+    for _, child := range node.Children {
+      check(child, !prover)
+    }
+  }
+  check(root.Children[0], false)
+  if len(root.Children[0].Children) == 0 {
+    t.Errorf("Expected white answers")
+  }
+}
+
+func TestProblemSymmetries(t *testing.T) {
+  // The enclosure of the bent three is kept by the diagonals and by the
+  // half turn, which take the target to other stones of its group.
+  problem := newProblem(6, 6, bent_three, engine.BLACK, KILL)
+  problem.TargetY, problem.TargetX = 1, 2
+  problem.Region = Enclosure(problem.Goban, 1, 2)
+  symmetries := problemSymmetries(problem)
+  expected := []engine.Symmetry{
+    engine.IDENTITY, engine.MIRROR_X | engine.MIRROR_Y, engine.TRANSPOSE,
+    engine.MIRROR_X | engine.MIRROR_Y | engine.TRANSPOSE,
+  }
+  if fmt.Sprint(symmetries) != fmt.Sprint(expected) {
+    t.Errorf("Expected %v, actual %v", expected, symmetries)
+  }
+  // A region along the first row is only kept by mirroring the columns.
+  for i := range problem.Region {
+    problem.Region[i] = i < 6
+  }
+  symmetries = problemSymmetries(problem)
+  expected = []engine.Symmetry{engine.IDENTITY, engine.MIRROR_X}
+  if fmt.Sprint(symmetries) != fmt.Sprint(expected) {
+    t.Errorf("Expected %v, actual %v", expected, symmetries)
+  }
+  // The corner ko has no symmetry.
+  problem = newProblem(3, 5, corner_ko, engine.BLACK, KILL)
+  if symmetries := problemSymmetries(problem); len(symmetries) != 1 {
+    t.Errorf("Expected only the identity, actual %v", symmetries)
+  }
+}
+
+func TestSolveErrors(t *testing.T) {
+  problem := newProblem(6, 6, bent_three, engine.BLACK, KILL)
+  problem.TargetY, problem.TargetX = 2, 2
+  if _, err := Solve(problem); err != ErrTarget {
+    t.Errorf("Expected %v, actual %v", ErrTarget, err)
+  }
+  problem.TargetY, problem.TargetX = 1, 2
+  solver := &Solver{MaxNodes: 5}
+  if _, err := solver.Solve(problem); err != ErrBudget {
+    t.Errorf("Expected %v, actual %v", ErrBudget, err)
+  }
+}
+
+func TestEnclosure(t *testing.T) {
+  g := engine.NewChainGoban(6, 6)
+  engine.FromString(g, bent_three)
+  region := Enclosure(g, 1, 2)
+  count := 0
+  for _, inside := range region {
+    if inside {
+      count++
+    }
+  }
+  // The white stones and the three points of the eye.
+  if count != 14 || !region[2 * 6 + 2] || region[0] {
+    t.Errorf("Wrong enclosure %v", region)
+  }
+}